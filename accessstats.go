@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accessStatsEnabled turns on counting the reads of each key through GET
+// /key. It is off by default since every read then also writes to SQLite.
+var accessStatsEnabled = os.Getenv("WEBREDIS_ACCESS_STATS") == "true"
+
+type keyAccess struct {
+	Key        string    `json:"key"`
+	Count      int64     `json:"count"`
+	LastAccess time.Time `json:"lastAccess"`
+}
+
+// recordKeyAccess counts a read of key when access stats are enabled. A
+// failure only loses the count, so it is logged rather than returned.
+func recordKeyAccess(id, db, key string) {
+	if !accessStatsEnabled {
+		return
+	}
+	if err := incrementKeyAccess(id, db, key, time.Now()); err != nil {
+		log.Printf("Warning: Failed to record key access: %v", err)
+	}
+}
+
+// getAccessStats returns the keys of a database read most often through GET
+// /key, ?count at a time (default 50). Keys that were deleted since keep
+// their counts.
+func getAccessStats(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	if _, ok := clientForDB(c, id, db); !ok {
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "50"))
+	if err != nil || count <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid count")
+		return
+	}
+
+	keys, err := topKeyAccesses(id, db, count)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to load access stats: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled": accessStatsEnabled,
+		"keys":    keys,
+	})
+}
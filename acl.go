@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type aclKeyPattern struct {
+	Pattern string `json:"pattern"`
+	Read    bool   `json:"read"`
+	Write   bool   `json:"write"`
+}
+
+// aclPermissions is the ACL GETUSER reply of a user with its command rules
+// split so the UI can check an operation without parsing ACL syntax.
+type aclPermissions struct {
+	Flags             []string        `json:"flags"`
+	AllowedCommands   []string        `json:"allowedCommands"`
+	DeniedCommands    []string        `json:"deniedCommands"`
+	AllowedCategories []string        `json:"allowedCategories"`
+	DeniedCategories  []string        `json:"deniedCategories"`
+	Keys              []aclKeyPattern `json:"keys"`
+	Channels          []string        `json:"channels"`
+	Commands          string          `json:"commands"`
+	Selectors         interface{}     `json:"selectors,omitempty"`
+}
+
+// getACLWhoami returns the user the connection is authenticated as. Servers
+// older than 6.0 have no ACLs and report aclSupported false, meaning every
+// operation is allowed to the single default user.
+func getACLWhoami(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	user, err := client.Do(c, "ACL", "WHOAMI").Text()
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			c.JSON(http.StatusOK, gin.H{"aclSupported": false, "user": "default"})
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read current user: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"aclSupported": true, "user": user})
+}
+
+// getACLUser returns the permissions of the user the connection is
+// authenticated as, from ACL WHOAMI and ACL GETUSER.
+func getACLUser(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	user, err := client.Do(c, "ACL", "WHOAMI").Text()
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			c.JSON(http.StatusOK, gin.H{"aclSupported": false, "user": "default"})
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read current user: %v", err))
+		return
+	}
+
+	reply, err := client.Do(c, "ACL", "GETUSER", user).Result()
+	if errors.Is(err, redis.Nil) {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("User '%s' not found", user))
+		return
+	}
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "NOPERM") {
+			// The user may not inspect itself; the UI falls back to trying
+			// operations and showing the server's errors
+			respondError(c, http.StatusForbidden, errRedisError, fmt.Sprintf("User '%s' is not allowed to run ACL GETUSER", user))
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read user permissions: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"aclSupported": true,
+		"user":         user,
+		"permissions":  parseACLUser(reply),
+	})
+}
+
+// parseACLUser reads an ACL GETUSER reply. Redis 6 reports keys and channels
+// as pattern lists; Redis 7 as ACL rule strings such as "~* %R~cache:*".
+func parseACLUser(reply interface{}) aclPermissions {
+	fields := replyMap(reply)
+	perms := aclPermissions{
+		Flags:             replyStrings(fields["flags"]),
+		AllowedCommands:   []string{},
+		DeniedCommands:    []string{},
+		AllowedCategories: []string{},
+		DeniedCategories:  []string{},
+		Keys:              []aclKeyPattern{},
+		Channels:          []string{},
+		Selectors:         fields["selectors"],
+	}
+
+	perms.Commands = fmt.Sprint(fields["commands"])
+	for _, rule := range strings.Fields(perms.Commands) {
+		switch {
+		case strings.HasPrefix(rule, "+@"):
+			perms.AllowedCategories = append(perms.AllowedCategories, rule[2:])
+		case strings.HasPrefix(rule, "-@"):
+			perms.DeniedCategories = append(perms.DeniedCategories, rule[2:])
+		case strings.HasPrefix(rule, "+"):
+			perms.AllowedCommands = append(perms.AllowedCommands, rule[1:])
+		case strings.HasPrefix(rule, "-"):
+			perms.DeniedCommands = append(perms.DeniedCommands, rule[1:])
+		}
+	}
+
+	switch keys := fields["keys"].(type) {
+	case string:
+		for _, rule := range strings.Fields(keys) {
+			perms.Keys = append(perms.Keys, parseACLKeyRule(rule))
+		}
+	default:
+		for _, pattern := range replyStrings(keys) {
+			perms.Keys = append(perms.Keys, aclKeyPattern{Pattern: pattern, Read: true, Write: true})
+		}
+	}
+
+	switch channels := fields["channels"].(type) {
+	case string:
+		for _, rule := range strings.Fields(channels) {
+			if rule == "allchannels" {
+				rule = "&*"
+			}
+			perms.Channels = append(perms.Channels, strings.TrimPrefix(rule, "&"))
+		}
+	default:
+		perms.Channels = append(perms.Channels, replyStrings(channels)...)
+	}
+
+	return perms
+}
+
+// parseACLKeyRule reads one key rule: "~pattern" grants read and write,
+// "%R~pattern", "%W~pattern" and "%RW~pattern" only what they name.
+func parseACLKeyRule(rule string) aclKeyPattern {
+	if rule == "allkeys" {
+		return aclKeyPattern{Pattern: "*", Read: true, Write: true}
+	}
+	if pattern, found := strings.CutPrefix(rule, "~"); found {
+		return aclKeyPattern{Pattern: pattern, Read: true, Write: true}
+	}
+	access, pattern, _ := strings.Cut(strings.TrimPrefix(rule, "%"), "~")
+	access = strings.ToUpper(access)
+	return aclKeyPattern{
+		Pattern: pattern,
+		Read:    strings.Contains(access, "R"),
+		Write:   strings.Contains(access, "W"),
+	}
+}
+
+// replyStrings converts an array reply into strings, or an empty slice when
+// the reply is not an array.
+func replyStrings(reply interface{}) []string {
+	items, _ := reply.([]interface{})
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, fmt.Sprint(item))
+	}
+	return result
+}
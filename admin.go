@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// adminMode enables operations that can hurt a running Redis server or the
+// data in it. It is off unless WEBREDIS_ADMIN_MODE is set to "true".
+var adminMode = os.Getenv("WEBREDIS_ADMIN_MODE") == "true"
+
+// adminToken, when set, must also be sent in the X-Admin-Token header of every
+// admin operation, so admin mode can be enabled on a shared deployment
+// without handing it to every user.
+var adminToken = os.Getenv("WEBREDIS_ADMIN_TOKEN")
+
+// adminTokenProtocol prefixes the WebSocket subprotocol carrying the admin
+// token, since browsers cannot set headers on a WebSocket handshake:
+// new WebSocket(url, ["webredis", "admin-token.<token>"]).
+const adminTokenProtocol = "admin-token."
+
+// requireAdmin aborts the request with 403 unless admin mode is enabled and,
+// if configured, the admin token matches.
+func requireAdmin(c *gin.Context) bool {
+	if !adminMode {
+		respondError(c, http.StatusForbidden, errAdminRequired, "This operation requires WEBREDIS_ADMIN_MODE=true")
+		return false
+	}
+	if adminToken != "" && subtle.ConstantTimeCompare([]byte(requestAdminToken(c.Request)), []byte(adminToken)) != 1 {
+		respondError(c, http.StatusForbidden, errAdminRequired, "This operation requires a valid X-Admin-Token header, or for WebSockets an \"admin-token.<token>\" subprotocol")
+		return false
+	}
+	return true
+}
+
+// requestAdminToken returns the admin token of a request: the X-Admin-Token
+// header or, for a WebSocket handshake, the adminTokenProtocol subprotocol.
+func requestAdminToken(r *http.Request) string {
+	if token := r.Header.Get("X-Admin-Token"); token != "" {
+		return token
+	}
+	if websocket.IsWebSocketUpgrade(r) {
+		for _, protocol := range websocket.Subprotocols(r) {
+			if token, ok := strings.CutPrefix(protocol, adminTokenProtocol); ok {
+				return token
+			}
+		}
+	}
+	return ""
+}
+
+// adminCommands are commands that always need admin mode, whatever their
+// flags say. Scripts are included because they can write without being
+// flagged as writes.
+var adminCommands = map[string]bool{
+	"FLUSHDB": true, "FLUSHALL": true, "SHUTDOWN": true, "DEBUG": true,
+	"BGSAVE": true, "SAVE": true, "BGREWRITEAOF": true,
+	"REPLICAOF": true, "SLAVEOF": true, "FAILOVER": true, "SWAPDB": true,
+	"MIGRATE": true, "RESTORE": true, "MONITOR": true,
+	"EVAL": true, "EVALSHA": true, "FCALL": true,
+}
+
+// adminSubcommands are the dangerous subcommands of container commands, whose
+// top-level COMMAND INFO flags do not describe them. Redis 7.0+ also flags
+// each subcommand, which commandNeedsAdmin checks as well; this list covers
+// older servers and subcommands that only hurt the shared pooled connection.
+var adminSubcommands = map[string]map[string]bool{
+	"CONFIG":   {"SET": true, "RESETSTAT": true, "REWRITE": true},
+	"CLIENT":   {"KILL": true, "PAUSE": true, "UNBLOCK": true, "NO-EVICT": true, "NO-TOUCH": true, "REPLY": true, "TRACKING": true, "SETNAME": true, "SETINFO": true, "CACHING": true},
+	"XGROUP":   {"CREATE": true, "CREATECONSUMER": true, "DELCONSUMER": true, "DESTROY": true, "SETID": true},
+	"ACL":      {"SETUSER": true, "DELUSER": true, "LOAD": true, "SAVE": true},
+	"SCRIPT":   {"FLUSH": true, "KILL": true, "LOAD": true},
+	"FUNCTION": {"LOAD": true, "DELETE": true, "FLUSH": true, "KILL": true, "RESTORE": true},
+	"MODULE":   {"LOAD": true, "LOADEX": true, "UNLOAD": true},
+	"CLUSTER":  {"RESET": true, "FAILOVER": true, "FORGET": true, "MEET": true, "ADDSLOTS": true, "DELSLOTS": true, "SETSLOT": true, "FLUSHSLOTS": true},
+	"MEMORY":   {"PURGE": true},
+	"SLOWLOG":  {"RESET": true},
+	"LATENCY":  {"RESET": true},
+}
+
+// commandNeedsAdmin reports whether a console command may modify data or the
+// server: anything in the lists above, or flagged write, admin or
+// may_replicate by COMMAND INFO. On Redis 7.0+ a container command such as
+// XGROUP has no flags of its own, so the flags of the subcommand are read
+// with COMMAND INFO xgroup|create. Unknown commands and subcommands and
+// failed lookups need admin, so the check fails closed.
+func commandNeedsAdmin(ctx context.Context, client *redis.Client, command string, args []string) bool {
+	name := strings.ToUpper(command)
+	if adminCommands[name] {
+		return true
+	}
+	if subcommands, ok := adminSubcommands[name]; ok && len(args) > 0 && subcommands[strings.ToUpper(args[0])] {
+		return true
+	}
+
+	info, ok := commandInfo(ctx, client, strings.ToLower(command))
+	if !ok || hasWriteFlag(info) {
+		return true
+	}
+	if isContainerEntry(info) {
+		if len(args) == 0 {
+			return true
+		}
+		sub, ok := commandInfo(ctx, client, strings.ToLower(command+"|"+args[0]))
+		return !ok || hasWriteFlag(sub)
+	}
+	return false
+}
+
+// commandInfo returns the COMMAND INFO entry of a command or "container|sub"
+// subcommand; ok is false for unknown ones and failed lookups.
+func commandInfo(ctx context.Context, client *redis.Client, name string) ([]interface{}, bool) {
+	reply, err := client.Do(ctx, "COMMAND", "INFO", name).Slice()
+	if err != nil || len(reply) == 0 {
+		return nil, false
+	}
+	info, ok := reply[0].([]interface{})
+	if !ok || len(info) < 3 {
+		return nil, false
+	}
+	return info, true
+}
+
+// isContainerEntry reports whether a COMMAND INFO entry has subcommands,
+// listed last in the entries of Redis 7.0+.
+func isContainerEntry(info []interface{}) bool {
+	if len(info) <= 9 {
+		return false
+	}
+	subcommands, _ := info[9].([]interface{})
+	return len(subcommands) > 0
+}
+
+// hasWriteFlag reports whether a COMMAND INFO entry is flagged write, admin
+// or may_replicate.
+func hasWriteFlag(info []interface{}) bool {
+	flags, _ := normalizeReply(info[2]).([]interface{})
+	for _, flag := range flags {
+		flagName, _ := flag.(string)
+		switch strings.ToLower(flagName) {
+		case "write", "admin", "may_replicate":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// anomalyMaxResults caps how many keys are listed per rule. Counts are always
+// complete.
+var anomalyMaxResults = envInt("WEBREDIS_ANOMALY_MAX_RESULTS", 1000)
+
+type typeRule struct {
+	Pattern string
+	Type    string
+}
+
+type anomalyGroup struct {
+	Count int                      `json:"count"`
+	Keys  []map[string]interface{} `json:"keys"`
+}
+
+func (g *anomalyGroup) add(entry map[string]interface{}) {
+	g.Count++
+	if len(g.Keys) < anomalyMaxResults {
+		g.Keys = append(g.Keys, entry)
+	}
+}
+
+// findAnomalies scans the database and reports keys breaking one of the rules
+// given in the query string. It only reports, nothing is changed.
+//
+//	volatile=session:*        keys matching the pattern must have a TTL
+//	maxLength=100000          collections must not be longer than this
+//	type=user:*=hash          keys matching the pattern must be of that type
+//
+// volatile and type can be repeated. Empty collections are always reported.
+func findAnomalies(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	volatile := c.QueryArray("volatile")
+	var types []typeRule
+	for _, rule := range c.QueryArray("type") {
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 || i == len(rule)-1 {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid type rule '%s', expected pattern=type", rule))
+			return
+		}
+		types = append(types, typeRule{Pattern: rule[:i], Type: rule[i+1:]})
+	}
+	var maxLength int64
+	if s := c.Query("maxLength"); s != "" {
+		var err error
+		maxLength, err = strconv.ParseInt(s, 10, 64)
+		if err != nil || maxLength <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid maxLength")
+			return
+		}
+	}
+
+	groups := map[string]*anomalyGroup{
+		"missingTtl":      {Keys: []map[string]interface{}{}},
+		"largeCollection": {Keys: []map[string]interface{}{}},
+		"emptyCollection": {Keys: []map[string]interface{}{}},
+		"wrongType":       {Keys: []map[string]interface{}{}},
+	}
+	scanned := 0
+	err := forEachKeyBatch(c, client, scopePattern(id, c.DefaultQuery("pattern", "*")), func(keys []string) error {
+		scanned += len(keys)
+		typeCmds := make([]*redis.StatusCmd, len(keys))
+		pttlCmds := make([]*redis.DurationCmd, len(keys))
+		_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				typeCmds[i] = pipe.Type(c, key)
+				pttlCmds[i] = pipe.PTTL(c, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read key metadata: %w", err)
+		}
+
+		lengthCmds := make([]*redis.Cmd, len(keys))
+		_, err = client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				keyType := typeCmds[i].Val()
+				if command, ok := lengthCommands[keyType]; ok && keyType != "string" {
+					lengthCmds[i] = pipe.Do(c, command, key)
+				}
+			}
+			return nil
+		})
+		if err != nil && !isWrongType(err) {
+			return fmt.Errorf("failed to read collection lengths: %w", err)
+		}
+
+		for i, key := range keys {
+			keyType := typeCmds[i].Val()
+			if keyType == "none" {
+				continue
+			}
+			if pttlCmds[i].Val() == -1 {
+				for _, pattern := range volatile {
+					if globMatch(pattern, key) {
+						groups["missingTtl"].add(map[string]interface{}{"key": key, "type": keyType, "rule": pattern})
+						break
+					}
+				}
+			}
+			for _, rule := range types {
+				if keyType != rule.Type && globMatch(rule.Pattern, key) {
+					groups["wrongType"].add(map[string]interface{}{"key": key, "type": keyType, "expected": rule.Type, "rule": rule.Pattern})
+					break
+				}
+			}
+			if lengthCmds[i] == nil {
+				continue
+			}
+			length, err := lengthCmds[i].Int64()
+			if err != nil {
+				continue
+			}
+			if length == 0 {
+				groups["emptyCollection"].add(map[string]interface{}{"key": key, "type": keyType})
+			} else if maxLength > 0 && length > maxLength {
+				groups["largeCollection"].add(map[string]interface{}{"key": key, "type": keyType, "length": length})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"scanned":   scanned,
+		"anomalies": groups,
+	})
+}
+
+// globMatch matches s against a Redis glob pattern: * and ? wildcards,
+// [abc], [^a] and [a-z] classes, and \ to escape. Unlike path.Match, * also
+// matches '/'. It runs in O(len(pattern) * len(s)) without recursion: on a
+// mismatch only the last * is retried, one byte further each time.
+func globMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, resume := -1, 0
+	for i < len(s) {
+		if p < len(pattern) && pattern[p] == '*' {
+			for p < len(pattern) && pattern[p] == '*' {
+				p++
+			}
+			if p == len(pattern) {
+				return true
+			}
+			star, resume = p, i
+			continue
+		}
+		if p < len(pattern) {
+			if next, ok := globMatchByte(pattern, p, s[i]); ok {
+				p, i = next, i+1
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		// Let the last * swallow one more byte
+		resume++
+		p, i = star, resume
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// globMatchByte matches the byte c against the pattern element at p, which is
+// not a *, and returns the index of the next element.
+func globMatchByte(pattern string, p int, c byte) (int, bool) {
+	switch pattern[p] {
+	case '?':
+		return p + 1, true
+	case '[':
+		end := globClassEnd(pattern, p)
+		if end < 0 {
+			// No closing bracket, match '[' literally like Redis does
+			return p + 1, c == '['
+		}
+		j := p + 1
+		negate := j < end && pattern[j] == '^'
+		if negate {
+			j++
+		}
+		matched := false
+		for ; j < end; j++ {
+			switch {
+			case pattern[j] == '\\' && j+1 < end:
+				j++
+				matched = matched || pattern[j] == c
+			case j+2 < end && pattern[j+1] == '-':
+				low, high := pattern[j], pattern[j+2]
+				if low > high {
+					low, high = high, low
+				}
+				matched = matched || (low <= c && c <= high)
+				j += 2
+			default:
+				matched = matched || pattern[j] == c
+			}
+		}
+		return end + 1, matched != negate
+	case '\\':
+		if p+1 < len(pattern) {
+			p++
+		}
+	}
+	return p + 1, pattern[p] == c
+}
+
+// globClassEnd returns the index of the ']' closing the class opened at p,
+// skipping escaped characters, or -1 if there is none.
+func globClassEnd(pattern string, p int) int {
+	for j := p + 1; j < len(pattern); j++ {
+		switch pattern[j] {
+		case '\\':
+			j++
+		case ']':
+			return j
+		}
+	}
+	return -1
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"*", "", true},
+		{"*", "any/thing", true},
+		{"", "", true},
+		{"", "a", false},
+		{"user:*", "user:42", true},
+		{"user:*", "order:42", false},
+		{"*:42", "user:42", true},
+		{"u*r:*2", "user:42", true},
+		{"u*r:*3", "user:42", false},
+		{"a**b", "ab", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{"h[]llo", "hllo", false},
+		{"h[\\]]llo", "h]llo", true},
+		{"h[\\-]llo", "h-llo", true},
+		{"a[bc", "a[bc", true},
+		{"a[bc", "abc", false},
+		{"\\*", "*", true},
+		{"\\*", "a", false},
+		{"a\\?c", "a?c", true},
+		{"a\\?c", "abc", false},
+		{"a\\", "a\\", true},
+		{"*[0-9]", "key9", true},
+		{"*[0-9]", "key", false},
+		{"a*b*c", "aXbYbZc", true},
+		{"a*b*c", "aXbYbZ", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.s); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestGlobMatchPathologicalPattern(t *testing.T) {
+	// Exponential for a recursive matcher
+	pattern := strings.Repeat("a*", 30) + "b"
+	s := strings.Repeat("a", 5000)
+	start := time.Now()
+	if globMatch(pattern, s) {
+		t.Error("matched a string without b")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v", elapsed)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// bitfieldType matches BITFIELD encodings such as u8 or i16.
+var bitfieldType = regexp.MustCompile(`^[iu][0-9]+$`)
+
+type bitfieldOp struct {
+	Op       string      `json:"op"`       // GET, SET or INCRBY
+	Type     string      `json:"type"`     // e.g. u8, i16
+	Offset   interface{} `json:"offset"`   // bit offset, or "#n" for the n-th field of Type
+	Value    int64       `json:"value"`    // SET value or INCRBY increment
+	Overflow string      `json:"overflow"` // WRAP, SAT or FAIL, applied to this op
+}
+
+type bitfieldResult struct {
+	Op     string `json:"op"`
+	Type   string `json:"type"`
+	Offset string `json:"offset"`
+	Result *int64 `json:"result"` // nil when an INCRBY or SET failed with OVERFLOW FAIL
+}
+
+// bitRangeArgs appends the optional start, end and unit (BYTE or BIT) query
+// parameters to args. BIT ranges need Redis 7.
+func bitRangeArgs(c *gin.Context, args []interface{}) ([]interface{}, error) {
+	start, hasStart := c.GetQuery("start")
+	end, hasEnd := c.GetQuery("end")
+	unit := strings.ToUpper(c.Query("unit"))
+	if !hasStart {
+		if hasEnd || unit != "" {
+			return nil, fmt.Errorf("start is required with end or unit")
+		}
+		return args, nil
+	}
+	if _, err := strconv.ParseInt(start, 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid start")
+	}
+	args = append(args, start)
+	if !hasEnd {
+		if unit != "" {
+			return nil, fmt.Errorf("end is required with unit")
+		}
+		return args, nil
+	}
+	if _, err := strconv.ParseInt(end, 10, 64); err != nil {
+		return nil, fmt.Errorf("Invalid end")
+	}
+	args = append(args, end)
+	switch unit {
+	case "":
+	case "BYTE", "BIT":
+		args = append(args, unit)
+	default:
+		return nil, fmt.Errorf("unit must be BYTE or BIT")
+	}
+	return args, nil
+}
+
+func respondBitError(c *gin.Context, client *redis.Client, key string, err error) {
+	if isWrongType(err) {
+		respondWrongType(c, client, key, "string")
+		return
+	}
+	respondRedisError(c, err, err.Error())
+}
+
+func getBitCount(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	args, err := bitRangeArgs(c, []interface{}{"BITCOUNT", key})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	count, err := client.Do(c, args...).Int64()
+	if err != nil {
+		respondBitError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// getBitPos returns the position of the first bit set to ?bit, or -1.
+func getBitPos(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	bit := c.DefaultQuery("bit", "1")
+	if bit != "0" && bit != "1" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "bit must be 0 or 1")
+		return
+	}
+	args, err := bitRangeArgs(c, []interface{}{"BITPOS", key, bit})
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	pos, err := client.Do(c, args...).Int64()
+	if err != nil {
+		respondBitError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"position": pos})
+}
+
+// runBitfield runs a list of typed BITFIELD operations and labels each reply
+// with the operation that produced it.
+func runBitfield(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Ops []bitfieldOp `json:"ops"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Ops) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ops is required")
+		return
+	}
+
+	args := []interface{}{"BITFIELD", key}
+	results := make([]bitfieldResult, len(data.Ops))
+	for i, op := range data.Ops {
+		name := strings.ToUpper(op.Op)
+		if !bitfieldType.MatchString(op.Type) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("ops[%d]: invalid type '%s', expected e.g. u8 or i16", i, op.Type))
+			return
+		}
+		if op.Offset == nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("ops[%d]: offset is required", i))
+			return
+		}
+		offset, err := bitfieldOffset(op.Offset)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("ops[%d]: %v", i, err))
+			return
+		}
+
+		if overflow := strings.ToUpper(op.Overflow); overflow != "" {
+			if overflow != "WRAP" && overflow != "SAT" && overflow != "FAIL" {
+				respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("ops[%d]: overflow must be WRAP, SAT or FAIL", i))
+				return
+			}
+			args = append(args, "OVERFLOW", overflow)
+		}
+		switch name {
+		case "GET":
+			args = append(args, name, op.Type, offset)
+		case "SET", "INCRBY":
+			args = append(args, name, op.Type, offset, op.Value)
+		default:
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("ops[%d]: op must be GET, SET or INCRBY", i))
+			return
+		}
+		results[i] = bitfieldResult{Op: name, Type: op.Type, Offset: offset}
+	}
+
+	reply, err := client.Do(c, args...).Slice()
+	if err != nil {
+		respondBitError(c, client, key, err)
+		return
+	}
+	for i, value := range reply {
+		if i >= len(results) {
+			break
+		}
+		if n, ok := value.(int64); ok {
+			results[i].Result = &n
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// bitfieldOffset formats a BITFIELD offset given as a JSON number or as a
+// string holding a bit offset or "#n". Large numbers decode as float64,
+// which fmt.Sprint would print in exponent form.
+func bitfieldOffset(v interface{}) (string, error) {
+	switch offset := v.(type) {
+	case float64:
+		if offset < 0 || offset != math.Trunc(offset) || offset > math.MaxUint32 {
+			return "", fmt.Errorf("offset %v is not a valid bit offset", offset)
+		}
+		return strconv.FormatFloat(offset, 'f', -1, 64), nil
+	case string:
+		digits := strings.TrimPrefix(offset, "#")
+		if _, err := strconv.ParseUint(digits, 10, 32); err != nil {
+			return "", fmt.Errorf("offset '%s' must be a bit offset or #n", offset)
+		}
+		return offset, nil
+	}
+	return "", fmt.Errorf("offset must be a number or a string such as \"#2\"")
+}
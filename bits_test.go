@@ -0,0 +1,37 @@
+package main
+
+import "testing"
+
+func TestBitfieldOffset(t *testing.T) {
+	tests := []struct {
+		offset  interface{}
+		want    string
+		wantErr bool
+	}{
+		{offset: float64(0), want: "0"},
+		{offset: float64(100), want: "100"},
+		{offset: float64(4294967295), want: "4294967295"},
+		{offset: float64(1e7), want: "10000000"},
+		{offset: "#2", want: "#2"},
+		{offset: "17", want: "17"},
+		{offset: float64(1.5), wantErr: true},
+		{offset: float64(-1), wantErr: true},
+		{offset: float64(4294967296), wantErr: true},
+		{offset: "#", wantErr: true},
+		{offset: "#-1", wantErr: true},
+		{offset: "1e3", wantErr: true},
+		{offset: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := bitfieldOffset(tt.offset)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("bitfieldOffset(%v) = %q, want an error", tt.offset, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("bitfieldOffset(%v) = %q, %v, want %q", tt.offset, got, err, tt.want)
+		}
+	}
+}
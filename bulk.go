@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanBatchSize is the SCAN COUNT hint used by bulk operations.
+const scanBatchSize = 1000
+
+// isBroadPattern reports whether a MATCH pattern has no literal prefix, so it
+// may touch every key in the database (e.g. "*" or "*:session").
+func isBroadPattern(pattern string) bool {
+	return pattern == "" || strings.ContainsAny(pattern[:1], "*?[")
+}
+
+// errStopScan can be returned by a forEachKeyBatch callback to end the scan
+// early without reporting an error.
+var errStopScan = errors.New("stop scan")
+
+// forEachKeyBatch scans the keys matching pattern and calls fn with each
+// non-empty batch. It stops at the first error or when the request is
+// cancelled.
+func forEachKeyBatch(c *gin.Context, client *redis.Client, pattern string, fn func(keys []string) error) error {
+	return forEachKeyBatchOfType(c, client, pattern, "", fn)
+}
+
+// forEachKeyBatchOfType is forEachKeyBatch with SCAN TYPE (Redis 6.0+), so
+// only keys of keyType are returned. An empty keyType scans every key.
+func forEachKeyBatchOfType(c *gin.Context, client *redis.Client, pattern, keyType string, fn func(keys []string) error) error {
+	var cursor uint64
+	for {
+		if err := c.Err(); err != nil {
+			return err
+		}
+		var (
+			keys []string
+			next uint64
+			err  error
+		)
+		if keyType != "" {
+			keys, next, err = client.ScanType(c, cursor, pattern, scanBatchSize, keyType).Result()
+		} else {
+			keys, next, err = client.Scan(c, cursor, pattern, scanBatchSize).Result()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scan keys: %w", err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				if errors.Is(err, errStopScan) {
+					return nil
+				}
+				return err
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func expireByPattern(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Pattern string `json:"pattern"`
+		TTL     int64  `json:"ttl"` // seconds
+		Confirm bool   `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Pattern == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "pattern is required")
+		return
+	}
+	if data.TTL <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ttl must be a positive number of seconds")
+		return
+	}
+	if isBroadPattern(data.Pattern) && !data.Confirm {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Pattern '%s' may match every key, resend with confirm=true", data.Pattern))
+		return
+	}
+
+	ttl := time.Duration(data.TTL) * time.Second
+	matched, updated := 0, 0
+	err := forEachKeyBatch(c, client, scopePattern(id, data.Pattern), func(keys []string) error {
+		matched += len(keys)
+		cmds, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Expire(c, key, ttl)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set expiry: %w", err)
+		}
+		for _, cmd := range cmds {
+			// EXPIRE returns false for keys that vanished since the scan
+			if cmd.(*redis.BoolCmd).Val() {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matched": matched,
+		"updated": updated,
+	})
+}
+
+// persistByPattern removes the TTL of every key matching a pattern with
+// PERSIST, the reverse of expireByPattern. updated counts the keys that had
+// one; the rest were already persistent or vanished since the scan.
+func persistByPattern(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Pattern string `json:"pattern"`
+		Confirm bool   `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Pattern == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "pattern is required")
+		return
+	}
+	if isBroadPattern(data.Pattern) && !data.Confirm {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Pattern '%s' may match every key, resend with confirm=true", data.Pattern))
+		return
+	}
+
+	matched, updated := 0, 0
+	err := forEachKeyBatch(c, client, scopePattern(id, data.Pattern), func(keys []string) error {
+		matched += len(keys)
+		cmds, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Persist(c, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to remove expiry: %w", err)
+		}
+		for _, cmd := range cmds {
+			if cmd.(*redis.BoolCmd).Val() {
+				updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matched": matched,
+		"updated": updated,
+	})
+}
+
+// touchKeys updates the access time (and LFU counter) of keys without reading
+// them, for the listed keys or every key matching a pattern. existed counts
+// the keys TOUCH found.
+func touchKeys(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Keys    []string `json:"keys"`
+		Pattern string   `json:"pattern"`
+		Confirm bool     `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if (len(data.Keys) == 0) == (data.Pattern == "") {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Provide either keys or pattern")
+		return
+	}
+
+	if len(data.Keys) > 0 {
+		if !requireKeysInScope(c, id, data.Keys...) {
+			return
+		}
+		var existed int64
+		for start := 0; start < len(data.Keys); start += scanBatchSize {
+			end := min(start+scanBatchSize, len(data.Keys))
+			n, err := client.Touch(c, data.Keys[start:end]...).Result()
+			if err != nil {
+				respondRedisError(c, err, fmt.Sprintf("Failed to touch keys: %v", err))
+				return
+			}
+			existed += n
+		}
+		c.JSON(http.StatusOK, gin.H{"existed": existed})
+		return
+	}
+
+	if isBroadPattern(data.Pattern) && !data.Confirm {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Pattern '%s' may match every key, resend with confirm=true", data.Pattern))
+		return
+	}
+
+	var matched, existed int64
+	err := forEachKeyBatch(c, client, scopePattern(id, data.Pattern), func(keys []string) error {
+		matched += int64(len(keys))
+		// One TOUCH per batch; keys that vanished since the scan are not counted
+		n, err := client.Touch(c, keys...).Result()
+		if err != nil {
+			return fmt.Errorf("failed to touch keys: %w", err)
+		}
+		existed += n
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"matched": matched,
+		"existed": existed,
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestForEachKeyBatchStopsWhenCancelled(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	// Every SCAN answers one key and a non-zero cursor, so the scan never
+	// finishes on its own
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] != "scan" {
+			return "+OK\r\n"
+		}
+		time.Sleep(delay)
+		return "*2\r\n$1\r\n1\r\n*1\r\n$1\r\nk\r\n"
+	})
+	client := redis.NewClient(&redis.Options{Addr: server.addr})
+	defer client.Close()
+
+	gin.SetMode(gin.TestMode)
+	c, engine := gin.CreateTestContext(httptest.NewRecorder())
+	ctx, cancel := context.WithCancel(context.Background())
+	c.Request = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	// As set on the router, so c.Done() follows the request context
+	engine.ContextWithFallback = true
+
+	batches := 0
+	time.AfterFunc(5*delay, cancel)
+	start := time.Now()
+	err := forEachKeyBatch(c, client, "*", func(keys []string) error {
+		batches++
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("scan returned %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*delay {
+		t.Errorf("scan returned %v after it was cancelled at %v", elapsed, 5*delay)
+	}
+	if batches == 0 {
+		t.Error("scan did not run before it was cancelled")
+	}
+	if stats := client.PoolStats(); stats.TotalConns != stats.IdleConns {
+		t.Errorf("%d pool connections still in use", stats.TotalConns-stats.IdleConns)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// changelogSize is how many operations are kept per watched key.
+	changelogSize = envInt("WEBREDIS_CHANGELOG_SIZE", 100)
+	// changelogMaxKeys caps the watched keys per connection.
+	changelogMaxKeys = envInt("WEBREDIS_CHANGELOG_MAX_KEYS", 100)
+)
+
+type changelogEntry struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+}
+
+// keyChangelog is the ring buffer of the latest operations on one key.
+type keyChangelog struct {
+	since   time.Time
+	entries []changelogEntry
+	next    int // slot the next entry goes to once the buffer is full
+}
+
+func (changes *keyChangelog) add(entry changelogEntry) {
+	if len(changes.entries) < changelogSize {
+		changes.entries = append(changes.entries, entry)
+		return
+	}
+	changes.entries[changes.next] = entry
+	changes.next = (changes.next + 1) % changelogSize
+}
+
+// ordered returns the entries oldest first.
+func (changes *keyChangelog) ordered() []changelogEntry {
+	result := make([]changelogEntry, 0, len(changes.entries))
+	result = append(result, changes.entries[changes.next:]...)
+	return append(result, changes.entries[:changes.next]...)
+}
+
+// changelogWatcher holds the keyspace notification subscription of one
+// connection. Every watched key adds a channel to the same subscription, so a
+// connection uses a single extra server connection however many keys are
+// watched.
+type changelogWatcher struct {
+	pubsub *redis.PubSub
+	logs   map[string]*keyChangelog // by channel, "__keyspace@<db>__:<key>"
+}
+
+var (
+	changelogWatchers   = make(map[string]*changelogWatcher)
+	changelogWatchersMu sync.Mutex
+)
+
+func keyspaceChannel(index int, key string) string {
+	return fmt.Sprintf("__keyspace@%d__:%s", index, key)
+}
+
+// watchKeyChanges starts recording the operations on a key from keyspace
+// notifications. The server must publish them: notify-keyspace-events needs
+// K plus the event classes of interest, e.g. "KA".
+func watchKeyChanges(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	if _, ok := clientForDB(c, id, db); !ok {
+		return
+	}
+	index, _ := strconv.Atoi(db)
+	base, _ := getConnection(id)
+
+	// CONFIG may be disabled on managed servers, then the watch is started
+	// anyway and stays empty if notifications are off
+	if config, err := base.ConfigGet(c, "notify-keyspace-events").Result(); err == nil {
+		if flags := config["notify-keyspace-events"]; !strings.Contains(flags, "K") {
+			respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("Keyspace notifications are disabled (notify-keyspace-events is '%s'); set it to include K and the event classes, e.g. 'KA'", flags))
+			return
+		}
+	}
+
+	// The lock is never held across a round trip to the server, so a slow
+	// SUBSCRIBE does not stall the notifications of other keys
+	channel := keyspaceChannel(index, key)
+	for {
+		changelogWatchersMu.Lock()
+		watcher, exists := changelogWatchers[id]
+		if exists {
+			if _, watching := watcher.logs[channel]; watching {
+				changelogWatchersMu.Unlock()
+				c.JSON(http.StatusOK, gin.H{"watching": true})
+				return
+			}
+			if len(watcher.logs) >= changelogMaxKeys {
+				changelogWatchersMu.Unlock()
+				respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("At most %d keys can be watched per connection", changelogMaxKeys))
+				return
+			}
+			// Reserved before subscribing, so concurrent watches of other
+			// keys count it against the limit
+			changes := &keyChangelog{since: time.Now()}
+			watcher.logs[channel] = changes
+			changelogWatchersMu.Unlock()
+
+			if err := watcher.pubsub.Subscribe(c, channel); err != nil {
+				changelogWatchersMu.Lock()
+				if watcher.logs[channel] == changes {
+					delete(watcher.logs, channel)
+				}
+				changelogWatchersMu.Unlock()
+				respondRedisError(c, err, fmt.Sprintf("Failed to subscribe: %v", err))
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"watching": true})
+			return
+		}
+		changelogWatchersMu.Unlock()
+
+		// Not tied to the request, the subscription outlives it
+		pubsub := base.Subscribe(context.Background(), channel)
+		if _, err := pubsub.Receive(c); err != nil {
+			pubsub.Close()
+			respondRedisError(c, err, fmt.Sprintf("Failed to subscribe: %v", err))
+			return
+		}
+
+		changelogWatchersMu.Lock()
+		if _, raced := changelogWatchers[id]; raced {
+			// Another request started the subscription meanwhile; add the
+			// key to that one instead
+			changelogWatchersMu.Unlock()
+			pubsub.Close()
+			continue
+		}
+		if current, ok := getConnection(id); !ok || current != base {
+			// The connection was deleted or saved again meanwhile
+			changelogWatchersMu.Unlock()
+			pubsub.Close()
+			respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+			return
+		}
+		watcher = &changelogWatcher{pubsub: pubsub, logs: map[string]*keyChangelog{channel: {since: time.Now()}}}
+		changelogWatchers[id] = watcher
+		changelogWatchersMu.Unlock()
+		go recordKeyChanges(watcher)
+
+		c.JSON(http.StatusOK, gin.H{"watching": true})
+		return
+	}
+}
+
+// recordKeyChanges appends every notification to the changelog of its key
+// until the subscription is closed. The message payload is the operation,
+// e.g. "set", "del" or "expired".
+func recordKeyChanges(watcher *changelogWatcher) {
+	for msg := range watcher.pubsub.Channel() {
+		changelogWatchersMu.Lock()
+		if changes, ok := watcher.logs[msg.Channel]; ok {
+			changes.add(changelogEntry{Event: msg.Payload, Time: time.Now()})
+		}
+		changelogWatchersMu.Unlock()
+	}
+}
+
+// getKeyChangelog returns the recorded operations of a watched key, oldest
+// first.
+func getKeyChangelog(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	if _, ok := clientForDB(c, id, db); !ok {
+		return
+	}
+
+	index, _ := strconv.Atoi(db)
+
+	changelogWatchersMu.Lock()
+	defer changelogWatchersMu.Unlock()
+
+	channel := keyspaceChannel(index, key)
+	watcher, exists := changelogWatchers[id]
+	if !exists || watcher.logs[channel] == nil {
+		respondError(c, http.StatusNotFound, errNotFound, "Key is not being watched, POST to this URL to start recording changes")
+		return
+	}
+	changes := watcher.logs[channel]
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":   changes.since,
+		"entries": changes.ordered(),
+	})
+}
+
+// unwatchKeyChanges stops recording a key and drops its changelog.
+func unwatchKeyChanges(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	if _, ok := clientForDB(c, id, db); !ok {
+		return
+	}
+
+	index, _ := strconv.Atoi(db)
+
+	changelogWatchersMu.Lock()
+	channel := keyspaceChannel(index, key)
+	watcher, exists := changelogWatchers[id]
+	if !exists || watcher.logs[channel] == nil {
+		changelogWatchersMu.Unlock()
+		respondError(c, http.StatusNotFound, errNotFound, "Key is not being watched")
+		return
+	}
+	delete(watcher.logs, channel)
+	last := len(watcher.logs) == 0
+	if last {
+		delete(changelogWatchers, id)
+	}
+	changelogWatchersMu.Unlock()
+
+	if last {
+		watcher.pubsub.Close()
+	} else if err := watcher.pubsub.Unsubscribe(c, channel); err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to unsubscribe: %v", err))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// forgetChangelogs closes the subscription of a deleted connection and drops
+// its changelogs.
+func forgetChangelogs(id string) {
+	changelogWatchersMu.Lock()
+	defer changelogWatchersMu.Unlock()
+
+	if watcher, ok := changelogWatchers[id]; ok {
+		watcher.pubsub.Close()
+		delete(changelogWatchers, id)
+	}
+}
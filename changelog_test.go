@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestKeyspaceChannel(t *testing.T) {
+	if got := keyspaceChannel(1, "user:1"); got != "__keyspace@1__:user:1" {
+		t.Errorf("keyspaceChannel(1, user:1) = %q", got)
+	}
+}
+
+func TestKeyChangelogRing(t *testing.T) {
+	previous := changelogSize
+	changelogSize = 3
+	defer func() { changelogSize = previous }()
+
+	changes := &keyChangelog{}
+	for _, event := range []string{"set", "expire", "del", "set", "hset"} {
+		changes.add(changelogEntry{Event: event})
+	}
+	var events []string
+	for _, entry := range changes.ordered() {
+		events = append(events, entry.Event)
+	}
+	if len(events) != 3 || events[0] != "del" || events[1] != "set" || events[2] != "hset" {
+		t.Errorf("ordered() = %v, want the last 3 oldest first", events)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// clearKeyScript empties KEYS[1] in one step. Strings become "" with their
+// TTL carried over (PTTL/SET PX rather than KEEPTTL, which needs 6.0) and
+// streams are trimmed to no entries, so both keep their key and TTL. Redis
+// cannot hold an empty list, set, hash or sorted set, so those are deleted.
+// It replies {type, kept, removed}: removed is the number of bytes or
+// elements cleared, kept is -1 for types it cannot clear.
+var clearKeyScript = redis.NewScript(`
+local keyType = redis.call('TYPE', KEYS[1])
+keyType = keyType.ok or keyType
+if keyType == 'none' then
+	return {keyType, 0, 0}
+elseif keyType == 'string' then
+	local removed = redis.call('STRLEN', KEYS[1])
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('SET', KEYS[1], '', 'PX', ttl)
+	else
+		redis.call('SET', KEYS[1], '')
+	end
+	return {keyType, 1, removed}
+elseif keyType == 'stream' then
+	return {keyType, 1, redis.call('XTRIM', KEYS[1], 'MAXLEN', 0)}
+end
+local lengths = {list = 'LLEN', set = 'SCARD', hash = 'HLEN', zset = 'ZCARD'}
+if not lengths[keyType] then
+	return {keyType, -1, 0}
+end
+local removed = redis.call(lengths[keyType], KEYS[1])
+redis.call('DEL', KEYS[1])
+return {keyType, 0, removed}
+`)
+
+// clearKey empties a key without deleting it where Redis allows that:
+// strings are set to "" and streams trimmed to no entries, both keeping
+// their TTL (and stream consumer groups). Empty lists, sets, hashes and
+// sorted sets cannot exist in Redis, so clearing one deletes the key; the
+// response then has kept false. removed is the number of bytes (strings) or
+// elements cleared.
+func clearKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	reply, err := clearKeyScript.Run(c, client, []string{key}).Slice()
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to clear key: %v", err))
+		return
+	}
+	if len(reply) != 3 {
+		respondError(c, http.StatusInternalServerError, errRedisError, fmt.Sprintf("Unexpected reply %v", reply))
+		return
+	}
+	keyType := fmt.Sprint(reply[0])
+	kept, _ := reply[1].(int64)
+	removed, _ := reply[2].(int64)
+
+	switch {
+	case keyType == "none":
+		respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+		return
+	case kept < 0:
+		respondError(c, http.StatusBadRequest, errUnsupportedType, fmt.Sprintf("Keys of type '%s' cannot be cleared", keyType))
+		return
+	case kept == 0:
+		adjustKeyCount(id, db, -1)
+	}
+
+	result := gin.H{
+		"type":    keyType,
+		"kept":    kept == 1,
+		"removed": removed,
+	}
+	if kept == 0 {
+		result["warning"] = fmt.Sprintf("Redis cannot hold an empty %s, so the key was deleted along with its TTL", keyType)
+	}
+	c.JSON(http.StatusOK, result)
+}
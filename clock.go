@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getServerTime returns the Redis server clock from TIME and its skew
+// against the webredis host. The server time is compared with the midpoint
+// of the round trip, so skewMs is accurate to about half of roundTripMs. A
+// positive skew means the server clock is ahead.
+func getServerTime(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	sent := time.Now()
+	serverTime, err := client.Time(c).Result()
+	received := time.Now()
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read server time: %v", err))
+		return
+	}
+
+	roundTrip := received.Sub(sent)
+	localTime := sent.Add(roundTrip / 2)
+	skew := serverTime.Sub(localTime)
+
+	c.JSON(http.StatusOK, gin.H{
+		"serverTimeUs": serverTime.UnixMicro(),
+		"serverTime":   serverTime.UTC().Format(time.RFC3339Nano),
+		"localTimeUs":  localTime.UnixMicro(),
+		"skewMs":       float64(skew.Microseconds()) / 1000,
+		"roundTripMs":  float64(roundTrip.Microseconds()) / 1000,
+	})
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type clusterSlotNode struct {
+	ID   string `json:"id"`
+	Addr string `json:"addr"`
+	Role string `json:"role"` // "master" or "replica"
+}
+
+type clusterSlotRange struct {
+	Start int               `json:"start"`
+	End   int               `json:"end"`
+	Nodes []clusterSlotNode `json:"nodes"`
+}
+
+type clusterNode struct {
+	ID          string   `json:"id"`
+	Addr        string   `json:"addr"`
+	Flags       []string `json:"flags"`
+	Master      string   `json:"master,omitempty"` // ID of the master, for replicas
+	PingSent    int64    `json:"pingSent"`
+	PongRecv    int64    `json:"pongRecv"`
+	ConfigEpoch int64    `json:"configEpoch"`
+	LinkState   string   `json:"linkState"`
+	Slots       []string `json:"slots"` // "0-5460", "5461" or migration markers
+}
+
+// isClusterDisabled reports whether the server refused a CLUSTER command
+// because it does not run in cluster mode.
+func isClusterDisabled(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "cluster support disabled")
+}
+
+// clusterClient returns the client of a connection, responding 404 for an
+// unknown connection.
+func clusterClient(c *gin.Context) (*redis.Client, bool) {
+	client, exists := getConnection(c.Param("id"))
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return nil, false
+	}
+	return client, true
+}
+
+// readClusterSlots returns the slot ranges of the cluster with their master
+// first, then its replicas, as reported by CLUSTER SLOTS.
+func readClusterSlots(c *gin.Context, client *redis.Client) ([]clusterSlotRange, error) {
+	slots, err := cachedReply(c, c.Param("id"), "CLUSTER SLOTS", func() ([]redis.ClusterSlot, error) {
+		return client.ClusterSlots(c).Result()
+	})
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]clusterSlotRange, len(slots))
+	for i, slot := range slots {
+		ranges[i] = clusterSlotRange{Start: slot.Start, End: slot.End, Nodes: make([]clusterSlotNode, len(slot.Nodes))}
+		for j, node := range slot.Nodes {
+			role := "replica"
+			if j == 0 {
+				role = "master"
+			}
+			ranges[i].Nodes[j] = clusterSlotNode{ID: node.ID, Addr: node.Addr, Role: role}
+		}
+	}
+	return ranges, nil
+}
+
+// getClusterSlots returns which node serves each slot range. Non-cluster
+// servers get a 400.
+func getClusterSlots(c *gin.Context) {
+	client, ok := clusterClient(c)
+	if !ok {
+		return
+	}
+
+	ranges, err := readClusterSlots(c, client)
+	if err != nil {
+		if isClusterDisabled(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, "The server does not run in cluster mode")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read cluster slots: %v", err))
+		return
+	}
+
+	covered := 0
+	for _, r := range ranges {
+		covered += r.End - r.Start + 1
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"slots":        ranges,
+		"slotsCovered": covered,
+	})
+}
+
+// getClusterNodes returns CLUSTER NODES parsed into one entry per node.
+func getClusterNodes(c *gin.Context) {
+	client, ok := clusterClient(c)
+	if !ok {
+		return
+	}
+
+	raw, err := client.ClusterNodes(c).Result()
+	if err != nil {
+		if isClusterDisabled(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, "The server does not run in cluster mode")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read cluster nodes: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"nodes": parseClusterNodes(raw)})
+}
+
+// getClusterKeySlot returns the slot a key hashes to and the nodes serving
+// it, to trace hot spots back to a node.
+func getClusterKeySlot(c *gin.Context) {
+	client, ok := clusterClient(c)
+	if !ok {
+		return
+	}
+	key := c.Query("key")
+	if key == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "key is required")
+		return
+	}
+
+	slot, err := client.ClusterKeySlot(c, key).Result()
+	if err != nil {
+		if isClusterDisabled(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, "The server does not run in cluster mode")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to compute key slot: %v", err))
+		return
+	}
+	ranges, err := readClusterSlots(c, client)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read cluster slots: %v", err))
+		return
+	}
+
+	result := gin.H{"key": key, "slot": slot}
+	if tag, ok := keyHashTag(key); ok {
+		result["hashTag"] = tag
+	}
+	// A slot outside every range is unassigned, e.g. while resharding
+	for _, r := range ranges {
+		if int64(r.Start) <= slot && slot <= int64(r.End) {
+			result["nodes"] = r.Nodes
+			break
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// keyHashTag returns the part of a key that is hashed instead of the whole
+// key: the text between the first "{" and the next "}", when not empty.
+func keyHashTag(key string) (string, bool) {
+	start := strings.IndexByte(key, '{')
+	if start < 0 {
+		return "", false
+	}
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return "", false
+	}
+	return key[start+1 : start+1+end], true
+}
+
+// parseClusterNodes reads CLUSTER NODES lines:
+// "<id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot>...".
+func parseClusterNodes(raw string) []clusterNode {
+	nodes := []clusterNode{}
+	for _, line := range strings.Split(raw, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		node := clusterNode{
+			ID:        fields[0],
+			Addr:      fields[1],
+			Flags:     strings.Split(fields[2], ","),
+			LinkState: fields[7],
+			Slots:     append([]string{}, fields[8:]...),
+		}
+		// Keep host:port, dropping the cluster bus port and hostname
+		if addr, _, found := strings.Cut(node.Addr, "@"); found {
+			node.Addr = addr
+		}
+		if fields[3] != "-" {
+			node.Master = fields[3]
+		}
+		node.PingSent, _ = strconv.ParseInt(fields[4], 10, 64)
+		node.PongRecv, _ = strconv.ParseInt(fields[5], 10, 64)
+		node.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
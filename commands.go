@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// normalizeReply converts a raw Redis reply into values encoding/json can
+// handle. RESP3 map replies arrive as map[interface{}]interface{}, which JSON
+// cannot encode, and may be nested inside arrays and other maps. RESP3 sets
+// arrive as slices and need no change beyond their elements.
+func normalizeReply(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprint(key)] = normalizeReply(value)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[key] = normalizeReply(value)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, value := range v {
+			result[i] = normalizeReply(value)
+		}
+		return result
+	case error:
+		// Nested error replies, e.g. inside EXEC or a RESP3 attribute
+		return v.Error()
+	default:
+		return v
+	}
+}
+
+// replyMap turns a RESP2 flat [k1, v1, k2, v2, ...] array or a RESP3 map
+// reply into a Go map with string keys.
+func replyMap(reply interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	switch v := reply.(type) {
+	case []interface{}:
+		for i := 0; i+1 < len(v); i += 2 {
+			result[fmt.Sprint(v[i])] = normalizeReply(v[i+1])
+		}
+	case map[interface{}]interface{}:
+		for key, value := range v {
+			result[fmt.Sprint(key)] = normalizeReply(value)
+		}
+	}
+	return result
+}
+
+// parseCommandDoc converts one COMMAND DOCS entry into JSON-friendly maps,
+// including its nested argument and subcommand specs.
+func parseCommandDoc(reply interface{}) map[string]interface{} {
+	doc := replyMap(reply)
+	if args, ok := doc["arguments"].([]interface{}); ok {
+		doc["arguments"] = parseCommandArgs(args)
+	}
+	if subcommands, ok := doc["subcommands"]; ok {
+		parsed := make(map[string]interface{})
+		for name, sub := range replyMap(subcommands) {
+			parsed[name] = parseCommandDoc(sub)
+		}
+		doc["subcommands"] = parsed
+	}
+	return doc
+}
+
+func parseCommandArgs(args []interface{}) []map[string]interface{} {
+	parsed := make([]map[string]interface{}, len(args))
+	for i, arg := range args {
+		spec := replyMap(arg)
+		// block and oneof arguments nest further arguments
+		if nested, ok := spec["arguments"].([]interface{}); ok {
+			spec["arguments"] = parseCommandArgs(nested)
+		}
+		parsed[i] = spec
+	}
+	return parsed
+}
+
+// getCommandDocs describes a command using the server's own introspection:
+// COMMAND DOCS (Redis 7+) for the summary and argument spec, and COMMAND INFO
+// for arity and flags, which also works on older servers.
+func getCommandDocs(c *gin.Context) {
+	id := c.Param("id")
+	name := strings.ToLower(c.Query("name"))
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+	if name == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "name is required")
+		return
+	}
+
+	infoReply, err := cachedReply(c, id, "COMMAND INFO "+name, func() ([]interface{}, error) {
+		return client.Do(c, "COMMAND", "INFO", name).Slice()
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read command info: %v", err))
+		return
+	}
+	if len(infoReply) == 0 || infoReply[0] == nil {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Unknown command '%s'", name))
+		return
+	}
+
+	result := gin.H{"name": name}
+	// COMMAND INFO entry: name, arity, flags, first key, last key, step, ...
+	if info, ok := infoReply[0].([]interface{}); ok && len(info) >= 3 {
+		result["arity"] = info[1]
+		result["flags"] = normalizeReply(info[2])
+	}
+
+	docsReply, err := cachedReply(c, id, "COMMAND DOCS "+name, func() (interface{}, error) {
+		return client.Do(c, "COMMAND", "DOCS", name).Result()
+	})
+	if err != nil {
+		// Servers before 7.0 only know COMMAND INFO
+		result["docsAvailable"] = false
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	docs := replyMap(docsReply)
+	if doc, ok := docs[name]; ok {
+		for field, value := range parseCommandDoc(doc) {
+			result[field] = value
+		}
+		result["docsAvailable"] = true
+	} else {
+		result["docsAvailable"] = false
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// commandCatalogTTL is how long the command list of a server is reused. It
+// only changes on upgrade or MODULE LOAD.
+const commandCatalogTTL = 10 * time.Minute
+
+type catalogCommand struct {
+	Name          string   `json:"name"`
+	Arity         int64    `json:"arity"` // negative: at least -arity arguments
+	Flags         []string `json:"flags"`
+	FirstKey      int64    `json:"firstKey"`
+	LastKey       int64    `json:"lastKey"`
+	Step          int64    `json:"step"`
+	ACLCategories []string `json:"aclCategories,omitempty"`
+	Subcommands   []string `json:"subcommands,omitempty"`
+}
+
+type commandCatalog struct {
+	commands  []catalogCommand
+	fetchedAt time.Time
+}
+
+var (
+	commandCatalogs   = make(map[string]commandCatalog)
+	commandCatalogsMu sync.Mutex
+)
+
+// getCommandCatalog lists every command of the server with its arity, flags
+// and key positions from COMMAND, sorted by name, for the console's
+// autocomplete. The reply runs to hundreds of kilobytes, so the parsed list
+// is cached per connection; ?refresh=true reloads it.
+func getCommandCatalog(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	if c.Query("refresh") == "true" {
+		forgetCommandCatalog(id)
+	}
+
+	commandCatalogsMu.Lock()
+	catalog, ok := commandCatalogs[id]
+	commandCatalogsMu.Unlock()
+	if !ok || time.Since(catalog.fetchedAt) >= commandCatalogTTL {
+		reply, err := client.Do(c, "COMMAND").Slice()
+		if err != nil {
+			respondRedisError(c, err, fmt.Sprintf("Failed to read commands: %v", err))
+			return
+		}
+		catalog = commandCatalog{commands: parseCommandCatalog(reply), fetchedAt: time.Now()}
+
+		commandCatalogsMu.Lock()
+		commandCatalogs[id] = catalog
+		commandCatalogsMu.Unlock()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(catalog.commands),
+		"commands":  catalog.commands,
+		"fetchedAt": catalog.fetchedAt,
+	})
+}
+
+// parseCommandCatalog reads COMMAND entries: name, arity, flags, first key,
+// last key, step, then ACL categories (6.0+) and, from 7.0, tips, key specs
+// and subcommands, which are entries of the same shape.
+func parseCommandCatalog(reply []interface{}) []catalogCommand {
+	commands := make([]catalogCommand, 0, len(reply))
+	for _, item := range reply {
+		entry, ok := item.([]interface{})
+		if !ok || len(entry) < 6 {
+			continue
+		}
+		command := catalogCommand{
+			Name:  strings.ToLower(fmt.Sprint(entry[0])),
+			Flags: replyStrings(entry[2]),
+		}
+		command.Arity, _ = entry[1].(int64)
+		command.FirstKey, _ = entry[3].(int64)
+		command.LastKey, _ = entry[4].(int64)
+		command.Step, _ = entry[5].(int64)
+		if len(entry) > 6 {
+			command.ACLCategories = replyStrings(entry[6])
+		}
+		if len(entry) > 9 {
+			subcommands, _ := entry[9].([]interface{})
+			for _, sub := range subcommands {
+				if fields, ok := sub.([]interface{}); ok && len(fields) > 0 {
+					command.Subcommands = append(command.Subcommands, strings.ToLower(fmt.Sprint(fields[0])))
+				}
+			}
+		}
+		commands = append(commands, command)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// forgetCommandCatalog drops the cached command list of a connection.
+func forgetCommandCatalog(id string) {
+	commandCatalogsMu.Lock()
+	delete(commandCatalogs, id)
+	commandCatalogsMu.Unlock()
+}
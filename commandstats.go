@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type commandStat struct {
+	Command       string  `json:"command"`
+	Calls         int64   `json:"calls"`
+	Usec          int64   `json:"usec"`
+	UsecPerCall   float64 `json:"usecPerCall"`
+	RejectedCalls int64   `json:"rejectedCalls"`
+	FailedCalls   int64   `json:"failedCalls"`
+}
+
+// getCommandStats returns INFO commandstats as one entry per command, most
+// called first. Subcommands are reported separately as "client|list".
+// rejectedCalls and failedCalls are only reported by Redis 6.2 and newer.
+func getCommandStats(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	raw, err := cachedReply(c, id, "INFO commandstats", func() (string, error) {
+		return client.Info(c, "commandstats").Result()
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read command stats: %v", err))
+		return
+	}
+
+	stats := parseCommandStats(raw)
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Calls != stats[j].Calls {
+			return stats[i].Calls > stats[j].Calls
+		}
+		return stats[i].Command < stats[j].Command
+	})
+
+	c.JSON(http.StatusOK, gin.H{"commands": stats})
+}
+
+// parseCommandStats reads lines such as
+// "cmdstat_get:calls=21,usec=175,usec_per_call=8.33,rejected_calls=0,failed_calls=0".
+func parseCommandStats(raw string) []commandStat {
+	stats := []commandStat{}
+	for _, line := range strings.Split(raw, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		command, isStat := strings.CutPrefix(name, "cmdstat_")
+		if !found || !isStat {
+			continue
+		}
+		stat := commandStat{Command: command}
+		for _, field := range strings.Split(value, ",") {
+			k, v, _ := strings.Cut(field, "=")
+			switch k {
+			case "calls":
+				stat.Calls, _ = strconv.ParseInt(v, 10, 64)
+			case "usec":
+				stat.Usec, _ = strconv.ParseInt(v, 10, 64)
+			case "usec_per_call":
+				stat.UsecPerCall, _ = strconv.ParseFloat(v, 64)
+			case "rejected_calls":
+				stat.RejectedCalls, _ = strconv.ParseInt(v, 10, 64)
+			case "failed_calls":
+				stat.FailedCalls, _ = strconv.ParseInt(v, 10, 64)
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// resetCommandStats runs CONFIG RESETSTAT, which clears the command stats
+// along with the other INFO counters (keyspace hits and misses, errors,
+// connections received, ...).
+func resetCommandStats(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	if err := client.ConfigResetStat(c).Err(); err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to reset stats: %v", err))
+		return
+	}
+	invalidateInfoReplies(id)
+
+	c.Status(http.StatusOK)
+}
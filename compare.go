@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type compareTarget struct {
+	ID  string `json:"id"`
+	DB  string `json:"db"`
+	Key string `json:"key"`
+}
+
+// rawKey is a key read without decoding, so values compare byte for byte.
+type rawKey struct {
+	Type    string
+	String  string
+	Members []string          // list items in order, set members
+	Hash    map[string]string // hash fields
+	ZSet    map[string]float64
+}
+
+// readRawKey reads a whole string, list, set, hash or sorted set. The type is
+// "none" when the key does not exist; other types are returned unread.
+func readRawKey(c *gin.Context, client *redis.Client, key string) (rawKey, error) {
+	keyType, err := client.Type(c, key).Result()
+	if err != nil {
+		return rawKey{}, err
+	}
+	raw := rawKey{Type: keyType}
+	switch keyType {
+	case "none":
+	case "string":
+		raw.String, err = client.Get(c, key).Result()
+	case "list":
+		raw.Members, err = client.LRange(c, key, 0, -1).Result()
+	case "set":
+		raw.Members, err = client.SMembers(c, key).Result()
+	case "hash":
+		raw.Hash, err = client.HGetAll(c, key).Result()
+	case "zset":
+		var members []redis.Z
+		members, err = client.ZRangeWithScores(c, key, 0, -1).Result()
+		raw.ZSet = make(map[string]float64, len(members))
+		for _, z := range members {
+			raw.ZSet[fmt.Sprint(z.Member)] = z.Score
+		}
+	}
+	// The key may have expired or changed type between TYPE and the read
+	if isWrongType(err) {
+		return raw, fmt.Errorf("key '%s' changed type while being read", key)
+	}
+	if errors.Is(err, redis.Nil) {
+		raw.Type = "none"
+		err = nil
+	}
+	return raw, err
+}
+
+// compareKeys reports whether two keys, possibly in different databases or
+// connections, hold the same value. Collections get a structured diff:
+// members (or fields) only in a, only in b, and for hashes and sorted sets
+// the entries whose value or score differ. Each list holds at most
+// WEBREDIS_DIFF_MAX_RESULTS entries.
+func compareKeys(c *gin.Context) {
+	var data struct {
+		A compareTarget `json:"a"`
+		B compareTarget `json:"b"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.A.ID == "" || data.A.Key == "" || data.B.ID == "" || data.B.Key == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "a and b need an id and a key")
+		return
+	}
+
+	clientA, ok := clientForDB(c, data.A.ID, data.A.DB)
+	if !ok {
+		return
+	}
+	clientB, ok := clientForDB(c, data.B.ID, data.B.DB)
+	if !ok {
+		return
+	}
+	if !requireKeysInScope(c, data.A.ID, data.A.Key) || !requireKeysInScope(c, data.B.ID, data.B.Key) {
+		return
+	}
+
+	a, err := readRawKey(c, clientA, data.A.Key)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read a: %v", err))
+		return
+	}
+	b, err := readRawKey(c, clientB, data.B.Key)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read b: %v", err))
+		return
+	}
+
+	result := gin.H{"typeA": a.Type, "typeB": b.Type}
+	if a.Type != b.Type || a.Type == "none" {
+		// Two missing keys are reported as equal
+		result["equal"] = a.Type == b.Type
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	switch a.Type {
+	case "string", "list", "set", "hash", "zset":
+	default:
+		respondError(c, http.StatusBadRequest, errUnsupportedType, fmt.Sprintf("Cannot compare keys of type %s", a.Type))
+		return
+	}
+
+	switch a.Type {
+	case "string":
+		result["equal"] = a.String == b.String
+		result["bytesEqual"] = a.String == b.String
+		result["lengthA"] = len(a.String)
+		result["lengthB"] = len(b.String)
+	case "list":
+		onlyA, onlyB := compareMembers(a.Members, b.Members)
+		sameOrder := len(a.Members) == len(b.Members)
+		for i := 0; sameOrder && i < len(a.Members); i++ {
+			sameOrder = a.Members[i] == b.Members[i]
+		}
+		result["equal"] = sameOrder
+		result["onlyInA"] = decodeValues(onlyA)
+		result["onlyInB"] = decodeValues(onlyB)
+		result["lengthA"] = len(a.Members)
+		result["lengthB"] = len(b.Members)
+	case "set":
+		onlyA, onlyB := compareMembers(a.Members, b.Members)
+		result["equal"] = len(onlyA) == 0 && len(onlyB) == 0
+		result["onlyInA"] = decodeValues(onlyA)
+		result["onlyInB"] = decodeValues(onlyB)
+	case "hash":
+		onlyA, onlyB := compareMembers(mapKeys(a.Hash), mapKeys(b.Hash))
+		changed := []gin.H{}
+		for _, field := range sortedKeys(a.Hash) {
+			if valueB, ok := b.Hash[field]; ok && valueB != a.Hash[field] && len(changed) < diffMaxResults {
+				changed = append(changed, gin.H{"field": field, "a": decodeValue(a.Hash[field]), "b": decodeValue(valueB)})
+			}
+		}
+		result["equal"] = len(onlyA) == 0 && len(onlyB) == 0 && len(changed) == 0
+		result["onlyInA"] = onlyA
+		result["onlyInB"] = onlyB
+		result["changed"] = changed
+	case "zset":
+		onlyA, onlyB := compareMembers(mapKeys(a.ZSet), mapKeys(b.ZSet))
+		changed := []gin.H{}
+		for _, member := range sortedKeys(a.ZSet) {
+			if scoreB, ok := b.ZSet[member]; ok && scoreB != a.ZSet[member] && len(changed) < diffMaxResults {
+				changed = append(changed, gin.H{"member": decodeValue(member), "a": a.ZSet[member], "b": scoreB})
+			}
+		}
+		result["equal"] = len(onlyA) == 0 && len(onlyB) == 0 && len(changed) == 0
+		result["onlyInA"] = decodeValues(onlyA)
+		result["onlyInB"] = decodeValues(onlyB)
+		result["changed"] = changed
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// compareMembers returns the distinct items only in a and only in b, sorted
+// and capped at diffMaxResults each.
+func compareMembers(a, b []string) (onlyA, onlyB []string) {
+	inA := make(map[string]bool, len(a))
+	for _, item := range a {
+		inA[item] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, item := range b {
+		inB[item] = true
+	}
+	onlyA, onlyB = []string{}, []string{}
+	for item := range inA {
+		if !inB[item] {
+			onlyA = append(onlyA, item)
+		}
+	}
+	for item := range inB {
+		if !inA[item] {
+			onlyB = append(onlyB, item)
+		}
+	}
+	sort.Strings(onlyA)
+	sort.Strings(onlyB)
+	return onlyA[:min(len(onlyA), diffMaxResults)], onlyB[:min(len(onlyB), diffMaxResults)]
+}
+
+func mapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := mapKeys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envInt reads a positive integer setting from the environment, falling back
+// to def when it is unset or invalid.
+func envInt(name string, def int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %d", name, value, def)
+		return def
+	}
+	return n
+}
+
+// envDuration reads a positive duration setting (e.g. "30s", "2m") from the
+// environment, falling back to def when it is unset or invalid.
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("Warning: Invalid %s %q, using %v", name, value, def)
+		return def
+	}
+	return d
+}
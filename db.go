@@ -2,24 +2,46 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Connection struct {
-	ID       string
-	Name     string
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	ID             string
+	Name           string
+	Host           string
+	Port           string
+	Password       string
+	DB             int
+	CommandTimeout int    // milliseconds, 0 means the default
+	ClientName     string // CLIENT SETNAME value, empty means webredis:<id>
+	Protocol       int    // RESP version, 0 means the go-redis default
+	MaxConcurrency int    // concurrent requests, 0 means unlimited
+	KeyPrefix      string // keys outside this prefix are hidden, empty means all
+	Mode           string // redis_mode detected when the connection was added
+	NoTouch        bool   // CLIENT NO-TOUCH ON, reads keep the LRU/LFU of keys
+	NoEvict        bool   // CLIENT NO-EVICT ON, exempt from client eviction
+	DefaultTTL     int    // seconds set by setKey when no TTL is given, 0 means none
 }
 
 var db *sql.DB
 
+// SQLite settings. WAL lets reads run while a write is in progress, and the
+// busy timeout makes a writer wait for the lock instead of failing with
+// "database is locked". With the default of a single open connection every
+// statement is serialized inside the process as well.
+var (
+	sqliteJournalMode  = os.Getenv("WEBREDIS_SQLITE_JOURNAL_MODE")
+	sqliteBusyTimeout  = envDuration("WEBREDIS_SQLITE_BUSY_TIMEOUT", 5*time.Second)
+	sqliteMaxOpenConns = envInt("WEBREDIS_SQLITE_MAX_OPEN_CONNS", 1)
+)
+
 func initDB() error {
 	// Create data directory if it doesn't exist
 	if err := os.MkdirAll("data", 0755); err != nil {
@@ -28,11 +50,27 @@ func initDB() error {
 
 	// Open database connection
 	var err error
+	journalMode := strings.ToUpper(sqliteJournalMode)
+	switch journalMode {
+	case "":
+		journalMode = "WAL"
+	case "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+	default:
+		return fmt.Errorf("invalid WEBREDIS_SQLITE_JOURNAL_MODE %q", sqliteJournalMode)
+	}
+	// Set through the DSN so every pooled connection gets them; _txlock
+	// takes the write lock when a transaction starts rather than on its
+	// first write, which avoids deadlocks between two upgrading readers
 	dbPath := filepath.Join("data", "connections.db")
-	db, err = sql.Open("sqlite3", dbPath)
+	dsn := fmt.Sprintf("file:%s?_journal_mode=%s&_busy_timeout=%d&_txlock=immediate", dbPath, journalMode, sqliteBusyTimeout.Milliseconds())
+	db, err = sql.Open("sqlite3", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open database: %v", err)
 	}
+	db.SetMaxOpenConns(sqliteMaxOpenConns)
+	if err := db.Ping(); err != nil {
+		return fmt.Errorf("failed to open database: %v", err)
+	}
 
 	// Create connections table if it doesn't exist
 	createTableSQL := `
@@ -50,20 +88,144 @@ func initDB() error {
 		return fmt.Errorf("failed to create table: %v", err)
 	}
 
+	// Environments group several connections, e.g. the shards of one dataset
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS environments (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS environment_members (
+		environment_id TEXT NOT NULL,
+		connection_id TEXT NOT NULL,
+		PRIMARY KEY (environment_id, connection_id)
+	);`)
+	if err != nil {
+		return fmt.Errorf("failed to create environment tables: %v", err)
+	}
+
+	// Free-form labels such as "env:prod" on connections
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS connection_tags (
+		connection_id TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		PRIMARY KEY (connection_id, tag)
+	);
+	CREATE INDEX IF NOT EXISTS connection_tags_tag ON connection_tags (tag);`)
+	if err != nil {
+		return fmt.Errorf("failed to create tag table: %v", err)
+	}
+
+	// Responses of requests sent with an Idempotency-Key header
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		method TEXT NOT NULL,
+		path TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		content_type TEXT NOT NULL,
+		body BLOB NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idempotency_keys_created_at ON idempotency_keys (created_at);`)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency table: %v", err)
+	}
+
+	// Reads per key through GET /key, when WEBREDIS_ACCESS_STATS is on
+	_, err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS key_access (
+		connection_id TEXT NOT NULL,
+		db TEXT NOT NULL,
+		key TEXT NOT NULL,
+		count INTEGER NOT NULL,
+		last_access INTEGER NOT NULL,
+		PRIMARY KEY (connection_id, db, key)
+	);
+	CREATE INDEX IF NOT EXISTS key_access_count ON key_access (connection_id, db, count);`)
+	if err != nil {
+		return fmt.Errorf("failed to create key access table: %v", err)
+	}
+
+	// Columns added after the initial schema
+	if err := ensureColumn("connections", "command_timeout", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "client_name", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "protocol", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "max_concurrency", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "key_prefix", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "mode", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "no_touch", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "no_evict", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := ensureColumn("connections", "default_ttl", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ensureColumn adds a column to an existing table if it is not there yet, so
+// databases created by older versions keep working.
+func ensureColumn(table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s schema: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return fmt.Errorf("failed to read %s schema: %v", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s schema: %v", table, err)
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %v", table, column, err)
+	}
 	return nil
 }
 
 func saveConnection(conn Connection) error {
 	query := `
-	INSERT OR REPLACE INTO connections (id, name, host, port, password, db)
-	VALUES (?, ?, ?, ?, ?, ?)`
+	INSERT OR REPLACE INTO connections (id, name, host, port, password, db, command_timeout, client_name, protocol, max_concurrency, key_prefix, mode, no_touch, no_evict, default_ttl)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
-	_, err := db.Exec(query, conn.ID, conn.Name, conn.Host, conn.Port, conn.Password, conn.DB)
+	_, err := db.Exec(query, conn.ID, conn.Name, conn.Host, conn.Port, conn.Password, conn.DB, conn.CommandTimeout, conn.ClientName, conn.Protocol, conn.MaxConcurrency, conn.KeyPrefix, conn.Mode, conn.NoTouch, conn.NoEvict, conn.DefaultTTL)
 	return err
 }
 
 func loadConnections() ([]Connection, error) {
-	query := `SELECT id, name, host, port, password, db FROM connections`
+	query := `SELECT id, name, host, port, password, db, command_timeout, client_name, protocol, max_concurrency, key_prefix, mode, no_touch, no_evict, default_ttl FROM connections`
 	rows, err := db.Query(query)
 	if err != nil {
 		return nil, err
@@ -73,7 +235,7 @@ func loadConnections() ([]Connection, error) {
 	var connections []Connection
 	for rows.Next() {
 		var conn Connection
-		err := rows.Scan(&conn.ID, &conn.Name, &conn.Host, &conn.Port, &conn.Password, &conn.DB)
+		err := rows.Scan(&conn.ID, &conn.Name, &conn.Host, &conn.Port, &conn.Password, &conn.DB, &conn.CommandTimeout, &conn.ClientName, &conn.Protocol, &conn.MaxConcurrency, &conn.KeyPrefix, &conn.Mode, &conn.NoTouch, &conn.NoEvict, &conn.DefaultTTL)
 		if err != nil {
 			return nil, err
 		}
@@ -84,17 +246,325 @@ func loadConnections() ([]Connection, error) {
 }
 
 func deleteConnectionFromDB(id string) error {
-	query := `DELETE FROM connections WHERE id = ?`
-	_, err := db.Exec(query, id)
-	return err
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM environment_members WHERE connection_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM connection_tags WHERE connection_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM key_access WHERE connection_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM connections WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// errConnectionExists is returned by renameConnectionInDB when the new ID is
+// already taken.
+var errConnectionExists = errors.New("connection already exists")
+
+// renameConnectionInDB changes the ID of a saved connection. Every table that
+// references a connection ID must be updated inside this transaction, so a
+// failure never leaves rows pointing at an ID that no longer exists.
+func renameConnectionInDB(oldID, newID string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taken int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM connections WHERE id = ?`, newID).Scan(&taken); err != nil {
+		return err
+	}
+	if taken > 0 {
+		return errConnectionExists
+	}
+
+	result, err := tx.Exec(`UPDATE connections SET id = ? WHERE id = ?`, newID, oldID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec(`UPDATE environment_members SET connection_id = ? WHERE connection_id = ?`, newID, oldID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE connection_tags SET connection_id = ? WHERE connection_id = ?`, newID, oldID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE key_access SET connection_id = ? WHERE connection_id = ?`, newID, oldID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func getConnectionFromDB(id string) (Connection, error) {
-	query := `SELECT id, name, host, port, password, db FROM connections WHERE id = ?`
+	query := `SELECT id, name, host, port, password, db, command_timeout, client_name, protocol, max_concurrency, key_prefix, mode, no_touch, no_evict, default_ttl FROM connections WHERE id = ?`
 	var conn Connection
-	err := db.QueryRow(query, id).Scan(&conn.ID, &conn.Name, &conn.Host, &conn.Port, &conn.Password, &conn.DB)
+	err := db.QueryRow(query, id).Scan(&conn.ID, &conn.Name, &conn.Host, &conn.Port, &conn.Password, &conn.DB, &conn.CommandTimeout, &conn.ClientName, &conn.Protocol, &conn.MaxConcurrency, &conn.KeyPrefix, &conn.Mode, &conn.NoTouch, &conn.NoEvict, &conn.DefaultTTL)
 	if err != nil {
 		return Connection{}, err
 	}
 	return conn, nil
 }
+
+// loadConnectionTags returns the tags of every tagged connection, sorted.
+func loadConnectionTags() (map[string][]string, error) {
+	rows, err := db.Query(`SELECT connection_id, tag FROM connection_tags ORDER BY connection_id, tag`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := make(map[string][]string)
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		tags[id] = append(tags[id], tag)
+	}
+	return tags, rows.Err()
+}
+
+// connectionIDsWithTags returns the saved connections carrying every one of
+// tags.
+func connectionIDsWithTags(tags []string) (map[string]bool, error) {
+	// A tag given twice must not be counted twice in the HAVING clause
+	seen := make(map[string]bool, len(tags))
+	args := make([]interface{}, 0, len(tags)+1)
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			args = append(args, tag)
+		}
+	}
+	distinct := len(args)
+	args = append(args, distinct)
+	rows, err := db.Query(`
+	SELECT c.id
+	FROM connections c JOIN connection_tags t ON t.connection_id = c.id
+	WHERE t.tag IN (?`+strings.Repeat(", ?", distinct-1)+`)
+	GROUP BY c.id
+	HAVING COUNT(DISTINCT t.tag) = ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// addConnectionTags tags a connection, ignoring tags it already has.
+func addConnectionTags(id string, tags []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO connection_tags (connection_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// removeConnectionTag reports whether the connection had the tag.
+func removeConnectionTag(id, tag string) (bool, error) {
+	result, err := db.Exec(`DELETE FROM connection_tags WHERE connection_id = ? AND tag = ?`, id, tag)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+type Environment struct {
+	ID          string
+	Name        string
+	Connections []string
+}
+
+// saveEnvironment creates or replaces an environment and its member list.
+func saveEnvironment(env Environment) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO environments (id, name) VALUES (?, ?)`, env.ID, env.Name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM environment_members WHERE environment_id = ?`, env.ID); err != nil {
+		return err
+	}
+	for _, connID := range env.Connections {
+		if _, err := tx.Exec(`INSERT INTO environment_members (environment_id, connection_id) VALUES (?, ?)`, env.ID, connID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func loadEnvironments() ([]Environment, error) {
+	rows, err := db.Query(`
+	SELECT e.id, e.name, m.connection_id
+	FROM environments e LEFT JOIN environment_members m ON m.environment_id = e.id
+	ORDER BY e.id, m.connection_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var envs []Environment
+	for rows.Next() {
+		var (
+			id, name string
+			connID   sql.NullString
+		)
+		if err := rows.Scan(&id, &name, &connID); err != nil {
+			return nil, err
+		}
+		if len(envs) == 0 || envs[len(envs)-1].ID != id {
+			envs = append(envs, Environment{ID: id, Name: name, Connections: []string{}})
+		}
+		if connID.Valid {
+			last := &envs[len(envs)-1]
+			last.Connections = append(last.Connections, connID.String)
+		}
+	}
+	return envs, rows.Err()
+}
+
+func getEnvironmentFromDB(id string) (Environment, error) {
+	envs, err := loadEnvironments()
+	if err != nil {
+		return Environment{}, err
+	}
+	for _, env := range envs {
+		if env.ID == id {
+			return env, nil
+		}
+	}
+	return Environment{}, sql.ErrNoRows
+}
+
+func deleteEnvironmentFromDB(id string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM environment_members WHERE environment_id = ?`, id); err != nil {
+		return false, err
+	}
+	result, err := tx.Exec(`DELETE FROM environments WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, tx.Commit()
+}
+
+// storedResponse is the response recorded for an idempotency key.
+type storedResponse struct {
+	Method      string
+	Path        string
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// getIdempotentResponse returns the response stored for key if it was
+// recorded after notBefore.
+func getIdempotentResponse(key string, notBefore time.Time) (storedResponse, error) {
+	query := `SELECT method, path, status, content_type, body FROM idempotency_keys WHERE key = ? AND created_at >= ?`
+	var resp storedResponse
+	err := db.QueryRow(query, key, notBefore.Unix()).Scan(&resp.Method, &resp.Path, &resp.Status, &resp.ContentType, &resp.Body)
+	if err != nil {
+		return storedResponse{}, err
+	}
+	return resp, nil
+}
+
+// saveIdempotentResponse records the response of key and drops the ones
+// recorded before expireBefore.
+func saveIdempotentResponse(key string, resp storedResponse, expireBefore time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM idempotency_keys WHERE created_at < ?`, expireBefore.Unix()); err != nil {
+		return err
+	}
+	query := `
+	INSERT OR REPLACE INTO idempotency_keys (key, method, path, status, content_type, body, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if _, err := tx.Exec(query, key, resp.Method, resp.Path, resp.Status, resp.ContentType, resp.Body, time.Now().Unix()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// incrementKeyAccess counts one read of key at the given time.
+func incrementKeyAccess(id, dbIndex, key string, at time.Time) error {
+	query := `
+	INSERT INTO key_access (connection_id, db, key, count, last_access) VALUES (?, ?, ?, 1, ?)
+	ON CONFLICT (connection_id, db, key) DO UPDATE SET count = count + 1, last_access = excluded.last_access`
+	_, err := db.Exec(query, id, dbIndex, key, at.Unix())
+	return err
+}
+
+// topKeyAccesses returns the limit most read keys of a database.
+func topKeyAccesses(id, dbIndex string, limit int) ([]keyAccess, error) {
+	query := `SELECT key, count, last_access FROM key_access WHERE connection_id = ? AND db = ? ORDER BY count DESC, key LIMIT ?`
+	rows, err := db.Query(query, id, dbIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []keyAccess{}
+	for rows.Next() {
+		var (
+			access     keyAccess
+			lastAccess int64
+		)
+		if err := rows.Scan(&access.Key, &access.Count, &lastAccess); err != nil {
+			return nil, err
+		}
+		access.LastAccess = time.Unix(lastAccess, 0).UTC()
+		keys = append(keys, access)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useTestDB points db at a fresh SQLite database in a temporary directory for
+// the duration of a test.
+func useTestDB(t *testing.T) {
+	t.Helper()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := initDB(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		os.Chdir(wd)
+	})
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	for _, maxOpenConns := range []int{1, 8} {
+		t.Run(fmt.Sprintf("maxOpenConns=%d", maxOpenConns), func(t *testing.T) {
+			previous := sqliteMaxOpenConns
+			sqliteMaxOpenConns = maxOpenConns
+			defer func() { sqliteMaxOpenConns = previous }()
+			useTestDB(t)
+
+			if err := saveConnection(Connection{ID: "shared", Name: "shared", Host: "localhost", Port: "6379"}); err != nil {
+				t.Fatal(err)
+			}
+
+			const writers, writes = 16, 25
+			errs := make(chan error, writers*writes*3)
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < writes; i++ {
+						errs <- incrementKeyAccess("shared", "0", "hot", time.Now())
+						errs <- addConnectionTags("shared", []string{fmt.Sprintf("w%d", w)})
+						key := fmt.Sprintf("key-%d-%d", w, i)
+						errs <- saveIdempotentResponse(key, storedResponse{Method: "POST", Path: "/", Status: 200, Body: []byte{}}, time.Now().Add(-time.Hour))
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				if err != nil {
+					t.Fatalf("concurrent write failed: %v", err)
+				}
+			}
+
+			top, err := topKeyAccesses("shared", "0", 1)
+			if err != nil || len(top) != 1 || top[0].Count != writers*writes {
+				t.Errorf("access count = %v, %v, want %d", top, err, writers*writes)
+			}
+			tags, err := loadConnectionTags()
+			if err != nil || len(tags["shared"]) != writers {
+				t.Errorf("tags = %v, %v, want %d", tags["shared"], err, writers)
+			}
+		})
+	}
+}
+
+func TestConnectionIDsWithTags(t *testing.T) {
+	useTestDB(t)
+	for _, id := range []string{"a", "b"} {
+		if err := saveConnection(Connection{ID: id, Name: id, Host: "localhost", Port: "6379"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := addConnectionTags("a", []string{"env:prod", "team:payments"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := addConnectionTags("b", []string{"env:prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tags []string
+		want []string
+	}{
+		{[]string{"env:prod"}, []string{"a", "b"}},
+		{[]string{"env:prod", "team:payments"}, []string{"a"}},
+		{[]string{"env:prod", "env:prod"}, []string{"a", "b"}},
+		{[]string{"team:payments", "env:prod", "team:payments"}, []string{"a"}},
+		{[]string{"env:dev"}, nil},
+	}
+	for _, tt := range tests {
+		ids, err := connectionIDsWithTags(tt.tags)
+		if err != nil {
+			t.Fatalf("%v: %v", tt.tags, err)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("%v matched %v, want %v", tt.tags, ids, tt.want)
+			continue
+		}
+		for _, id := range tt.want {
+			if !ids[id] {
+				t.Errorf("%v matched %v, want %v", tt.tags, ids, tt.want)
+			}
+		}
+	}
+}
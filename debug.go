@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// debugSubcommands are the DEBUG subcommands POST /debug accepts, from the
+// comma-separated WEBREDIS_DEBUG_SUBCOMMANDS or, by default, ones that only
+// inspect a key or tune a knob that can be set back.
+var debugSubcommands = loadDebugSubcommands(os.Getenv("WEBREDIS_DEBUG_SUBCOMMANDS"))
+
+// forbiddenDebugSubcommands block the server, crash it, reload its data or
+// fill its memory. They are refused even when configured.
+var forbiddenDebugSubcommands = map[string]bool{
+	"SLEEP": true, "PANIC": true, "SEGFAULT": true, "ASSERT": true, "OOM": true,
+	"RESTART": true, "CRASH-AND-RECOVER": true, "RELOAD": true, "LOADAOF": true,
+	"POPULATE": true, "JMAP": true,
+}
+
+func loadDebugSubcommands(value string) map[string]bool {
+	if value == "" {
+		value = "SET-ACTIVE-EXPIRE,QUICKLIST-PACKED-THRESHOLD,STRINGMATCH-LEN,OBJECT,HTSTATS,HTSTATS-KEY,DIGEST-VALUE"
+	}
+	allowed := make(map[string]bool)
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" && !forbiddenDebugSubcommands[name] {
+			allowed[name] = true
+		}
+	}
+	return allowed
+}
+
+// runDebugCommand runs DEBUG { "subcommand", "args": [...] } on a server and
+// returns its reply. Only the subcommands in debugSubcommands are accepted,
+// so experts get DEBUG without the arbitrary console. Requires admin mode.
+// Redis 7.0+ also refuses DEBUG unless enable-debug-command allows it.
+func runDebugCommand(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data struct {
+		Subcommand string   `json:"subcommand"`
+		Args       []string `json:"args"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	subcommand := strings.ToUpper(data.Subcommand)
+	if forbiddenDebugSubcommands[subcommand] {
+		respondError(c, http.StatusForbidden, errAdminRequired, fmt.Sprintf("DEBUG %s is never allowed", subcommand))
+		return
+	}
+	if !debugSubcommands[subcommand] {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("DEBUG %s is not allowed, allowed subcommands: %s", subcommand, strings.Join(sortedKeys(debugSubcommands), ", ")))
+		return
+	}
+
+	args := []interface{}{"DEBUG", subcommand}
+	for _, arg := range data.Args {
+		args = append(args, arg)
+	}
+	result, err := client.Do(c, args...).Result()
+	if err != nil {
+		if strings.Contains(err.Error(), "enable-debug-command") {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "DEBUG is disabled on this server (enable-debug-command)")
+			return
+		}
+		if isRedisServerError(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+			return
+		}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": normalizeReply(result)})
+}
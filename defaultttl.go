@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTTLs holds the DefaultTTL of every connection that sets one, for
+// cache-only servers where keys written through webredis should not live
+// forever. Connections without a default have no entry.
+var (
+	defaultTTLs   = make(map[string]time.Duration)
+	defaultTTLsMu sync.RWMutex
+)
+
+// setDefaultTTL configures the default TTL in seconds of connection id; 0
+// removes it.
+func setDefaultTTL(id string, seconds int) {
+	defaultTTLsMu.Lock()
+	defer defaultTTLsMu.Unlock()
+
+	if seconds <= 0 {
+		delete(defaultTTLs, id)
+		return
+	}
+	defaultTTLs[id] = time.Duration(seconds) * time.Second
+}
+
+// forgetDefaultTTL drops the default TTL of a deleted connection.
+func forgetDefaultTTL(id string) {
+	setDefaultTTL(id, 0)
+}
+
+func defaultTTLFor(id string) time.Duration {
+	defaultTTLsMu.RLock()
+	defer defaultTTLsMu.RUnlock()
+	return defaultTTLs[id]
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// diffMaxResults caps how many keys each list of a diff report contains. The
+// counts are always complete.
+var diffMaxResults = envInt("WEBREDIS_DIFF_MAX_RESULTS", 1000)
+
+// digestScript returns {type, sha1} for every key in KEYS, so values can be
+// compared without sending them over the network. Collections are
+// serialised in a canonical order (sets and hash fields sorted) and every
+// element is length-prefixed to keep the encoding unambiguous.
+var digestScript = redis.NewScript(`
+local out = {}
+for i, key in ipairs(KEYS) do
+	local t = redis.call('TYPE', key)['ok']
+	local parts = {}
+	if t == 'string' then
+		parts = {redis.call('GET', key)}
+	elseif t == 'list' then
+		parts = redis.call('LRANGE', key, 0, -1)
+	elseif t == 'set' then
+		parts = redis.call('SMEMBERS', key)
+		table.sort(parts)
+	elseif t == 'hash' then
+		local flat = redis.call('HGETALL', key)
+		local fields = {}
+		for j = 1, #flat, 2 do
+			fields[#fields + 1] = {flat[j], flat[j + 1]}
+		end
+		table.sort(fields, function(a, b) return a[1] < b[1] end)
+		for _, f in ipairs(fields) do
+			parts[#parts + 1] = f[1]
+			parts[#parts + 1] = f[2]
+		end
+	elseif t == 'zset' then
+		parts = redis.call('ZRANGE', key, 0, -1, 'WITHSCORES')
+	elseif t == 'stream' then
+		for _, entry in ipairs(redis.call('XRANGE', key, '-', '+')) do
+			parts[#parts + 1] = entry[1]
+			for _, v in ipairs(entry[2]) do
+				parts[#parts + 1] = v
+			end
+		end
+	end
+	local buf = {}
+	for j, p in ipairs(parts) do
+		buf[j] = #p .. ':' .. p
+	end
+	out[i] = {t, redis.sha1hex(table.concat(buf, ','))}
+end
+return out
+`)
+
+type keyDigest struct {
+	Type   string
+	Digest string
+}
+
+type diffTarget struct {
+	ID string `json:"id"`
+	DB string `json:"db"`
+}
+
+type diffEntry struct {
+	Key        string `json:"key"`
+	SourceType string `json:"sourceType"`
+	TargetType string `json:"targetType"`
+}
+
+// digestKeys scans the keys matching pattern and returns their digests.
+func digestKeys(c *gin.Context, client *redis.Client, pattern string) (map[string]keyDigest, error) {
+	digests := make(map[string]keyDigest)
+	err := forEachKeyBatch(c, client, pattern, func(keys []string) error {
+		reply, err := digestScript.Run(c, client, keys).Slice()
+		if err != nil {
+			return fmt.Errorf("failed to hash values: %w", err)
+		}
+		for i, item := range reply {
+			pair, ok := item.([]interface{})
+			if !ok || len(pair) != 2 || i >= len(keys) {
+				continue
+			}
+			keyType := fmt.Sprint(pair[0])
+			if keyType == "none" {
+				// Deleted since the scan
+				continue
+			}
+			digests[keys[i]] = keyDigest{Type: keyType, Digest: fmt.Sprint(pair[1])}
+		}
+		return nil
+	})
+	return digests, err
+}
+
+// diffConnections compares the keys matching a pattern in two databases,
+// possibly on different connections. Values are hashed on the Redis side, so
+// only digests travel, but hashing a very large key still runs as a single
+// script and blocks that server while it runs.
+func diffConnections(c *gin.Context) {
+	var data struct {
+		Source  diffTarget `json:"source"`
+		Target  diffTarget `json:"target"`
+		Pattern string     `json:"pattern"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Source.ID == "" || data.Target.ID == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "source and target connections are required")
+		return
+	}
+	if data.Pattern == "" {
+		data.Pattern = "*"
+	}
+
+	source, ok := clientForDB(c, data.Source.ID, data.Source.DB)
+	if !ok {
+		return
+	}
+	target, ok := clientForDB(c, data.Target.ID, data.Target.DB)
+	if !ok {
+		return
+	}
+
+	sourceDigests, err := digestKeys(c, source, scopePattern(data.Source.ID, data.Pattern))
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read source: %v", err))
+		return
+	}
+	targetDigests, err := digestKeys(c, target, scopePattern(data.Target.ID, data.Pattern))
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read target: %v", err))
+		return
+	}
+
+	onlyInSource := []string{}
+	onlyInTarget := []string{}
+	different := []diffEntry{}
+	matching := 0
+	for key, s := range sourceDigests {
+		t, ok := targetDigests[key]
+		switch {
+		case !ok:
+			onlyInSource = append(onlyInSource, key)
+		case s != t:
+			different = append(different, diffEntry{Key: key, SourceType: s.Type, TargetType: t.Type})
+		default:
+			matching++
+		}
+	}
+	for key := range targetDigests {
+		if _, ok := sourceDigests[key]; !ok {
+			onlyInTarget = append(onlyInTarget, key)
+		}
+	}
+
+	sort.Strings(onlyInSource)
+	sort.Strings(onlyInTarget)
+	sort.Slice(different, func(i, j int) bool { return different[i].Key < different[j].Key })
+
+	counts := gin.H{
+		"onlyInSource": len(onlyInSource),
+		"onlyInTarget": len(onlyInTarget),
+		"different":    len(different),
+		"matching":     matching,
+	}
+	truncated := len(onlyInSource) > diffMaxResults || len(onlyInTarget) > diffMaxResults || len(different) > diffMaxResults
+	if len(onlyInSource) > diffMaxResults {
+		onlyInSource = onlyInSource[:diffMaxResults]
+	}
+	if len(onlyInTarget) > diffMaxResults {
+		onlyInTarget = onlyInTarget[:diffMaxResults]
+	}
+	if len(different) > diffMaxResults {
+		different = different[:diffMaxResults]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"onlyInSource": onlyInSource,
+		"onlyInTarget": onlyInTarget,
+		"different":    different,
+		"matching":     matching,
+		"counts":       counts,
+		"truncated":    truncated,
+	})
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// encodingSampleSize is the default and maximum number of keys sampled by the
+// encoding report.
+var encodingSampleSize = envInt("WEBREDIS_ENCODING_SAMPLE_SIZE", 1000)
+
+type encodingSuggestion struct {
+	Key        string `json:"key"`
+	Type       string `json:"type"`
+	Encoding   string `json:"encoding"`
+	Length     int64  `json:"length"`
+	Setting    string `json:"setting"`
+	Threshold  int64  `json:"threshold"`
+	Suggestion string `json:"suggestion"`
+}
+
+// compactEncodingSettings lists, per type, the settings bounding the compact
+// encoding by entry count. Older servers use the ziplist names.
+var compactEncodingSettings = map[string][]string{
+	"hash": {"hash-max-listpack-entries", "hash-max-ziplist-entries"},
+	"zset": {"zset-max-listpack-entries", "zset-max-ziplist-entries"},
+	"set":  {"set-max-listpack-entries", "set-max-intset-entries"},
+	"list": {"list-max-listpack-size"},
+}
+
+// compactEncodings are the encodings suggestions aim for.
+var compactEncodings = map[string]bool{
+	"listpack": true,
+	"ziplist":  true,
+	"intset":   true,
+}
+
+// getEncodingReport samples keys and flags collections stored with a large
+// encoding although their size is within the compact encoding's limit. Redis
+// never converts a key back once it grew past a limit, and a single long
+// element also forces the large encoding, so the report is advisory: it
+// points at keys worth rewriting or settings worth reviewing.
+func getEncodingReport(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	sample := encodingSampleSize
+	if s := c.Query("sample"); s != "" {
+		var err error
+		sample, err = strconv.Atoi(s)
+		if err != nil || sample <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid sample")
+			return
+		}
+		sample = min(sample, encodingSampleSize)
+	}
+
+	config, err := cachedReply(c, id, "CONFIG GET *-max-*", func() (map[string]string, error) {
+		return client.ConfigGet(c, "*-max-*").Result()
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read encoding thresholds: %v", err))
+		return
+	}
+	thresholds := gin.H{}
+	for _, names := range compactEncodingSettings {
+		for _, name := range names {
+			if value, ok := config[name]; ok {
+				thresholds[name] = value
+			}
+		}
+	}
+
+	sampled := 0
+	encodings := make(map[string]int)
+	suggestions := []encodingSuggestion{}
+	err = forEachKeyBatch(c, client, scopePattern(id, c.DefaultQuery("pattern", "*")), func(keys []string) error {
+		if remaining := sample - sampled; len(keys) > remaining {
+			keys = keys[:remaining]
+		}
+		types := make([]*redis.StatusCmd, len(keys))
+		objectEncodings := make([]*redis.StringCmd, len(keys))
+		if _, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				types[i] = pipe.Type(c, key)
+				objectEncodings[i] = pipe.ObjectEncoding(c, key)
+			}
+			return nil
+		}); err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("failed to read encodings: %w", err)
+		}
+
+		// Only collections in a large encoding need their length
+		lengths := make([]*redis.IntCmd, len(keys))
+		if _, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				if compactEncodings[objectEncodings[i].Val()] {
+					continue
+				}
+				switch types[i].Val() {
+				case "hash":
+					lengths[i] = pipe.HLen(c, key)
+				case "zset":
+					lengths[i] = pipe.ZCard(c, key)
+				case "set":
+					lengths[i] = pipe.SCard(c, key)
+				case "list":
+					lengths[i] = pipe.LLen(c, key)
+				}
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to read lengths: %w", err)
+		}
+
+		for i, key := range keys {
+			keyType, encoding := types[i].Val(), objectEncodings[i].Val()
+			// Keys deleted since the scan have no encoding
+			if keyType == "none" || encoding == "" {
+				continue
+			}
+			sampled++
+			encodings[keyType+":"+encoding]++
+			if lengths[i] == nil {
+				continue
+			}
+			if suggestion, ok := suggestEncoding(config, keyType, encoding, lengths[i].Val()); ok {
+				suggestion.Key = key
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+		if sampled >= sample {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sampled":     sampled,
+		"encodings":   encodings,
+		"thresholds":  thresholds,
+		"suggestions": suggestions,
+	})
+}
+
+// suggestEncoding checks a collection stored in a large encoding against the
+// entry limit of its compact encoding.
+func suggestEncoding(config map[string]string, keyType, encoding string, length int64) (encodingSuggestion, bool) {
+	for _, setting := range compactEncodingSettings[keyType] {
+		value, ok := config[setting]
+		if !ok {
+			continue
+		}
+		threshold, err := strconv.ParseInt(value, 10, 64)
+		// A negative list-max-listpack-size limits bytes per node, not
+		// entries, and cannot be compared to a length
+		if err != nil || threshold <= 0 || length > threshold {
+			continue
+		}
+
+		suggestion := encodingSuggestion{
+			Type:      keyType,
+			Encoding:  encoding,
+			Length:    length,
+			Setting:   setting,
+			Threshold: threshold,
+		}
+		switch setting {
+		case "set-max-intset-entries":
+			suggestion.Suggestion = fmt.Sprintf("%d members fit %s (%d); if they are all integers, rewriting the set would store it as an intset", length, setting, threshold)
+		case "list-max-listpack-size":
+			suggestion.Suggestion = fmt.Sprintf("%d items fit %s (%d) but the list is a %s; it likely grew past the limit once, rewriting it would store it as a listpack", length, setting, threshold, encoding)
+		default:
+			valueSetting := keyType + "-max-listpack-value"
+			if _, ok := config[valueSetting]; !ok {
+				valueSetting = keyType + "-max-ziplist-value"
+			}
+			suggestion.Suggestion = fmt.Sprintf("%d entries fit %s (%d) but the key is a %s; an element longer than %s (%s) or an earlier larger size keeps it there, rewriting it may make it compact", length, setting, threshold, encoding, valueSetting, config[valueSetting])
+		}
+		return suggestion, true
+	}
+	return encodingSuggestion{}, false
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type RedisEnvironment struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Connections []string `json:"connections"`
+}
+
+func createEnvironment(c *gin.Context) {
+	var env RedisEnvironment
+	if err := c.ShouldBindJSON(&env); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if env.ID == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "id is required")
+		return
+	}
+	if len(env.Connections) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "connections is required")
+		return
+	}
+	for _, connID := range env.Connections {
+		if _, exists := getConnection(connID); !exists {
+			respondError(c, http.StatusNotFound, errConnectionNotFound, fmt.Sprintf("Connection '%s' not found", connID))
+			return
+		}
+	}
+	if env.Name == "" {
+		env.Name = env.ID
+	}
+
+	err := saveEnvironment(Environment{ID: env.ID, Name: env.Name, Connections: env.Connections})
+	if err != nil {
+		log.Printf("Failed to save environment: %v", err)
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to save environment: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, env)
+}
+
+func listEnvironments(c *gin.Context) {
+	envs, err := loadEnvironments()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to load environments: %v", err))
+		return
+	}
+
+	result := make([]RedisEnvironment, len(envs))
+	for i, env := range envs {
+		result[i] = RedisEnvironment{ID: env.ID, Name: env.Name, Connections: env.Connections}
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func deleteEnvironment(c *gin.Context) {
+	envID := c.Param("envId")
+	deleted, err := deleteEnvironmentFromDB(envID)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to delete environment: %v", err))
+		return
+	}
+	if !deleted {
+		respondError(c, http.StatusNotFound, errNotFound, "Environment not found")
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+type environmentKey struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+}
+
+// listEnvironmentKeys scans every connection of an environment concurrently,
+// each in its configured database, and merges the keys with the connection
+// they came from. ?limit caps the keys read per connection. A member that
+// fails is reported in errors rather than failing the whole listing.
+func listEnvironmentKeys(c *gin.Context) {
+	envID := c.Param("envId")
+	env, err := getEnvironmentFromDB(envID)
+	if errors.Is(err, sql.ErrNoRows) {
+		respondError(c, http.StatusNotFound, errNotFound, "Environment not found")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to load environment: %v", err))
+		return
+	}
+
+	pattern := c.DefaultQuery("pattern", "*")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+	if err != nil || limit <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid limit")
+		return
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		keys      = []environmentKey{}
+		failures  = map[string]string{}
+		truncated = map[string]bool{}
+	)
+	for _, connID := range env.Connections {
+		client, exists := getConnection(connID)
+		if !exists {
+			// Members started earlier may be writing failures already
+			mu.Lock()
+			failures[connID] = "Connection not found"
+			mu.Unlock()
+			continue
+		}
+		wg.Add(1)
+		go func(connID string, client *redis.Client) {
+			defer wg.Done()
+			found, more, err := scanLimited(c, client, scopePattern(connID, pattern), limit)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[connID] = err.Error()
+				return
+			}
+			for _, key := range found {
+				keys = append(keys, environmentKey{Key: key, Source: connID})
+			}
+			if more {
+				truncated[connID] = true
+			}
+		}(connID, client)
+	}
+	wg.Wait()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Key != keys[j].Key {
+			return keys[i].Key < keys[j].Key
+		}
+		return keys[i].Source < keys[j].Source
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"keys":      keys,
+		"errors":    failures,
+		"truncated": truncated,
+	})
+}
+
+// scanLimited collects up to limit distinct keys matching pattern and reports
+// whether more remain.
+func scanLimited(c *gin.Context, client *redis.Client, pattern string, limit int) ([]string, bool, error) {
+	seen := make(map[string]bool)
+	keys := []string{}
+	more := false
+	err := forEachKeyBatch(c, client, pattern, func(batch []string) error {
+		for _, key := range batch {
+			if seen[key] {
+				continue
+			}
+			if len(keys) == limit {
+				more = true
+				return errStopScan
+			}
+			seen[key] = true
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, more, err
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestListEnvironmentKeysReportsMissingMembers(t *testing.T) {
+	useTestDB(t)
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] == "scan" {
+			return "-ERR scan refused\r\n"
+		}
+		return "+OK\r\n"
+	})
+	// The live members fail while the missing ones are recorded, which the
+	// race detector catches if failures is written without the lock
+	env := Environment{ID: "env", Name: "env"}
+	for _, id := range []string{"a-live", "c-live"} {
+		setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+		defer func(id string) {
+			client, _ := removeConnection(id)
+			releaseConnection(id, client)
+		}(id)
+		env.Connections = append(env.Connections, id)
+	}
+	env.Connections = append(env.Connections, "b-missing", "d-missing")
+	if err := saveEnvironment(env); err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/environments/:envId/keys", listEnvironmentKeys)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/environments/env/keys", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("listing returned %d: %s", w.Code, w.Body.String())
+	}
+
+	var result struct {
+		Errors map[string]string `json:"errors"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &result)
+	if len(result.Errors) != 4 || result.Errors["b-missing"] != "Connection not found" {
+		t.Errorf("got errors %v, want all four members", result.Errors)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// errorCode is a machine-readable error identifier. Clients should branch on
+// the code rather than on the message text.
+type errorCode string
+
+const (
+	errBadRequest         errorCode = "BAD_REQUEST"
+	errConnectionNotFound errorCode = "CONNECTION_NOT_FOUND"
+	errConnectionFailed   errorCode = "CONNECTION_FAILED"
+	errKeyNotFound        errorCode = "KEY_NOT_FOUND"
+	errNotFound           errorCode = "NOT_FOUND"
+	errWrongType          errorCode = "WRONG_TYPE"
+	errUnsupportedType    errorCode = "UNSUPPORTED_TYPE"
+	errConflict           errorCode = "CONFLICT"
+	errKeyOutOfScope      errorCode = "KEY_OUT_OF_SCOPE"
+	errAdminRequired      errorCode = "ADMIN_REQUIRED"
+	errPayloadTooLarge    errorCode = "PAYLOAD_TOO_LARGE"
+	errTooManyRequests    errorCode = "TOO_MANY_REQUESTS"
+	errNotSupported       errorCode = "NOT_SUPPORTED"
+	errInternal           errorCode = "INTERNAL_ERROR"
+	errRedisTimeout       errorCode = "REDIS_TIMEOUT"
+	errRedisUnreachable   errorCode = "REDIS_UNREACHABLE"
+	errRedisError         errorCode = "REDIS_ERROR"
+)
+
+// respondError writes the error envelope:
+//
+//	{"error": {"code": "KEY_NOT_FOUND", "message": "..."}, "message": "..."}
+//
+// The top-level message is kept for clients written against the old
+// {"error": "<message>"} responses.
+func respondError(c *gin.Context, status int, code errorCode, message string) {
+	c.JSON(status, errorEnvelope(code, message))
+}
+
+// errorEnvelope is the body respondError writes, also used as the final line
+// of an NDJSON stream that fails after it started.
+func errorEnvelope(code errorCode, message string) gin.H {
+	return gin.H{
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+		"message": message,
+	}
+}
+
+// respondRedisError reports a failed Redis call, classifying timeouts,
+// unreachable servers and other Redis errors.
+func respondRedisError(c *gin.Context, err error, message string) {
+	respondError(c, redisErrorStatus(err), redisErrorCode(err), message)
+}
+
+// redisErrorCode classifies a failed Redis call.
+func redisErrorCode(err error) errorCode {
+	switch {
+	case isTimeout(err):
+		return errRedisTimeout
+	case isUnreachable(err):
+		return errRedisUnreachable
+	case isWrongType(err):
+		return errWrongType
+	}
+	return errRedisError
+}
+
+// isUnreachable reports whether err means the Redis server could not be
+// reached at all, as opposed to the server rejecting a command.
+func isUnreachable(err error) bool {
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.EOF) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// isTimeout reports whether err is a Redis command running past its deadline.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isWrongType reports whether Redis rejected a command because the key holds
+// a different type than the command expects.
+func isWrongType(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")
+}
+
+// isRedisServerError reports whether err is an error reply from the server,
+// such as a script error, as opposed to a network or client failure.
+func isRedisServerError(err error) bool {
+	var replyErr redis.Error
+	return errors.As(err, &replyErr)
+}
+
+// respondWrongType reports a WRONGTYPE failure as a 409. The key's type is read
+// again because another client may have replaced it since it was checked.
+func respondWrongType(c *gin.Context, client *redis.Client, key, expected string) {
+	current, err := client.Type(c, key).Result()
+	if err != nil {
+		current = "unknown"
+	}
+	respondError(c, http.StatusConflict, errWrongType, fmt.Sprintf("key is now of type %s, expected %s", current, expected))
+}
+
+// redisErrorStatus maps a failed Redis call to an HTTP status, reporting
+// command timeouts as 504 so they can be told apart from other failures.
+func redisErrorStatus(err error) int {
+	if isTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
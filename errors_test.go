@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type errorBody struct {
+	Error struct {
+		Code    errorCode `json:"code"`
+		Message string    `json:"message"`
+	} `json:"error"`
+	Message string `json:"message"`
+}
+
+func TestStreamErrorLineMatchesErrorResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	respondError(c, http.StatusBadRequest, errRedisError, "boom")
+
+	var response, line errorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	encoded, _ := json.Marshal(errorEnvelope(redisErrorCode(errors.New("boom")), "boom"))
+	if err := json.Unmarshal(encoded, &line); err != nil {
+		t.Fatal(err)
+	}
+	if line != response || line.Error.Code != errRedisError || line.Message != "boom" {
+		t.Errorf("stream error line %+v, want %+v", line, response)
+	}
+}
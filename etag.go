@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// keyListingETag fingerprints a page of listKeys from the scanned key names,
+// the cursors and DBSIZE. It is computed before any per-key lookup, so a
+// matching If-None-Match skips the TYPE/PTTL round trips entirely. Being
+// based on names only, it does not notice a changed TTL or a key replaced by
+// one of another type; it is a weak validator for polling, not a checksum.
+func keyListingETag(cursor, nextCursor uint64, dbSize int64, keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	h := sha1.New()
+	h.Write([]byte(strconv.FormatUint(cursor, 10) + "\n"))
+	h.Write([]byte(strconv.FormatUint(nextCursor, 10) + "\n"))
+	h.Write([]byte(strconv.FormatInt(dbSize, 10) + "\n"))
+	for _, key := range sorted {
+		h.Write([]byte(strconv.Itoa(len(key)) + ":"))
+		h.Write([]byte(key))
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag. Weak
+// comparison is used, as for GET requests.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// replyStreamChunk is how many elements are read from Redis per call while a
+// reply is streamed.
+const replyStreamChunk = 1000
+
+// errStreamArgs marks invalid arguments of a streamed command, reported as a
+// 400 when nothing has been written yet.
+var errStreamArgs = errors.New("invalid arguments")
+
+// replyStream writes the elements of a reply as NDJSON, one line per element,
+// flushing after every chunk. The 200 status and headers are only sent with
+// the first chunk, so argument and early Redis errors still get a normal
+// error response.
+type replyStream struct {
+	c       *gin.Context
+	encoder *json.Encoder
+	started bool
+}
+
+func (s *replyStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	s.c.Header("Content-Type", "application/x-ndjson")
+	s.c.Status(http.StatusOK)
+	s.encoder = json.NewEncoder(s.c.Writer)
+}
+
+func (s *replyStream) emit(items []interface{}) error {
+	s.start()
+	for _, item := range items {
+		if err := s.encoder.Encode(normalizeReply(item)); err != nil {
+			// The client went away
+			return err
+		}
+	}
+	s.c.Writer.Flush()
+	return nil
+}
+
+// replyStreamer reads a command's reply chunk by chunk with paged commands
+// and passes each chunk to emit, so the whole reply is never held in memory.
+type replyStreamer func(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error
+
+type streamableCommand struct {
+	keyType string // type of the key read, empty for KEYS
+	stream  replyStreamer
+}
+
+// streamableCommands are the commands executeCommandStream can page through.
+var streamableCommands = map[string]streamableCommand{
+	"LRANGE":   {"list", streamListRange},
+	"ZRANGE":   {"zset", streamZSetRange},
+	"SMEMBERS": {"set", streamSetMembers},
+	"HGETALL":  {"hash", streamHashEntries},
+	"XRANGE":   {"stream", streamStreamRange},
+	"KEYS":     {"", streamKeyNames},
+}
+
+// executeCommandStream runs a range command from the console and streams its
+// reply as NDJSON, one element per line, instead of building the whole reply
+// first. Only the commands in streamableCommands are accepted; they are read
+// in pages (LRANGE/ZRANGE by index, SSCAN, HSCAN, XRANGE COUNT, SCAN), so
+// the result is not a snapshot: elements changed while streaming may be
+// missed or repeated. HGETALL lines are [field, value] pairs and ZRANGE
+// WITHSCORES lines [member, score] pairs. A failure after the first line is
+// reported as a final line holding the error envelope of respondError.
+func executeCommandStream(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Command string   `json:"command"`
+		Args    []string `json:"args"`
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(executeMaxBytes))
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	command := strings.ToUpper(data.Command)
+	streamable, ok := streamableCommands[command]
+	if !ok {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("%s cannot be streamed, supported commands: %s", command, strings.Join(sortedKeys(streamableCommands), ", ")))
+		return
+	}
+	if streamable.keyType != "" && len(data.Args) > 0 && !requireKeysInScope(c, id, data.Args[0]) {
+		return
+	}
+
+	stream := &replyStream{c: c}
+	err := streamable.stream(c, client, id, data.Args, stream.emit)
+	switch {
+	case err == nil:
+		// An empty reply still gets a 200
+		stream.start()
+	case !stream.started && errors.Is(err, errStreamArgs):
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+	case !stream.started:
+		if isWrongType(err) {
+			respondWrongType(c, client, data.Args[0], streamable.keyType)
+			return
+		}
+		respondRedisError(c, err, err.Error())
+	case c.Err() == nil:
+		stream.encoder.Encode(errorEnvelope(redisErrorCode(err), err.Error()))
+		c.Writer.Flush()
+	}
+}
+
+// streamArgs checks the number of arguments of a streamed command.
+func streamArgs(command string, args []string, minArgs, maxArgs int) error {
+	if len(args) < minArgs || len(args) > maxArgs {
+		return fmt.Errorf("%w: wrong number of arguments for %s", errStreamArgs, command)
+	}
+	return nil
+}
+
+// resolveRange turns LRANGE/ZRANGE start and stop, possibly negative, into
+// absolute indexes for a collection of the given length. ok is false when the
+// range is empty.
+func resolveRange(startArg, stopArg string, length int64) (start, stop int64, ok bool, err error) {
+	start, err = strconv.ParseInt(startArg, 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("%w: start is not an integer", errStreamArgs)
+	}
+	stop, err = strconv.ParseInt(stopArg, 10, 64)
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("%w: stop is not an integer", errStreamArgs)
+	}
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if stop < 0 {
+		stop = length + stop
+	}
+	stop = min(stop, length-1)
+	return start, stop, start <= stop, nil
+}
+
+// streamListRange streams LRANGE key start stop.
+func streamListRange(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if err := streamArgs("LRANGE", args, 3, 3); err != nil {
+		return err
+	}
+	length, err := client.LLen(c, args[0]).Result()
+	if err != nil {
+		return err
+	}
+	start, stop, ok, err := resolveRange(args[1], args[2], length)
+	if err != nil || !ok {
+		return err
+	}
+	for from := start; from <= stop; from += replyStreamChunk {
+		items, err := client.LRange(c, args[0], from, min(from+replyStreamChunk-1, stop)).Result()
+		if err != nil {
+			return err
+		}
+		// The list shrank while streaming
+		if len(items) == 0 {
+			return nil
+		}
+		if err := emit(toInterfaces(items)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamZSetRange streams ZRANGE key start stop [WITHSCORES], by index only.
+func streamZSetRange(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if err := streamArgs("ZRANGE", args, 3, 4); err != nil {
+		return err
+	}
+	withScores := false
+	if len(args) == 4 {
+		if !strings.EqualFold(args[3], "WITHSCORES") {
+			return fmt.Errorf("%w: only WITHSCORES is supported when streaming ZRANGE", errStreamArgs)
+		}
+		withScores = true
+	}
+	length, err := client.ZCard(c, args[0]).Result()
+	if err != nil {
+		return err
+	}
+	start, stop, ok, err := resolveRange(args[1], args[2], length)
+	if err != nil || !ok {
+		return err
+	}
+	for from := start; from <= stop; from += replyStreamChunk {
+		to := min(from+replyStreamChunk-1, stop)
+		var items []interface{}
+		if withScores {
+			members, err := client.ZRangeWithScores(c, args[0], from, to).Result()
+			if err != nil {
+				return err
+			}
+			for _, z := range members {
+				items = append(items, []interface{}{z.Member, z.Score})
+			}
+		} else {
+			members, err := client.ZRange(c, args[0], from, to).Result()
+			if err != nil {
+				return err
+			}
+			items = toInterfaces(members)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := emit(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// streamSetMembers streams SMEMBERS key with SSCAN.
+func streamSetMembers(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if err := streamArgs("SMEMBERS", args, 1, 1); err != nil {
+		return err
+	}
+	var cursor uint64
+	for {
+		members, next, err := client.SScan(c, args[0], cursor, "", replyStreamChunk).Result()
+		if err != nil {
+			return err
+		}
+		if len(members) > 0 {
+			if err := emit(toInterfaces(members)); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// streamHashEntries streams HGETALL key with HSCAN, as [field, value] pairs.
+func streamHashEntries(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if err := streamArgs("HGETALL", args, 1, 1); err != nil {
+		return err
+	}
+	var cursor uint64
+	for {
+		flat, next, err := client.HScan(c, args[0], cursor, "", replyStreamChunk).Result()
+		if err != nil {
+			return err
+		}
+		items := make([]interface{}, 0, len(flat)/2)
+		for i := 0; i+1 < len(flat); i += 2 {
+			items = append(items, []interface{}{flat[i], flat[i+1]})
+		}
+		if len(items) > 0 {
+			if err := emit(items); err != nil {
+				return err
+			}
+		}
+		if cursor = next; cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// streamStreamRange streams XRANGE key start end [COUNT n], continuing after
+// the last ID of each page.
+func streamStreamRange(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if len(args) != 3 && len(args) != 5 {
+		return fmt.Errorf("%w: wrong number of arguments for XRANGE", errStreamArgs)
+	}
+	limit := int64(-1)
+	if len(args) == 5 {
+		if !strings.EqualFold(args[3], "COUNT") {
+			return fmt.Errorf("%w: only COUNT is supported when streaming XRANGE", errStreamArgs)
+		}
+		var err error
+		if limit, err = strconv.ParseInt(args[4], 10, 64); err != nil || limit < 0 {
+			return fmt.Errorf("%w: COUNT is not a positive integer", errStreamArgs)
+		}
+	}
+	start, end := args[1], args[2]
+	for limit != 0 {
+		count := int64(replyStreamChunk)
+		if limit > 0 {
+			count = min(count, limit)
+		}
+		messages, err := client.XRangeN(c, args[0], start, end, count).Result()
+		if err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+		items := make([]interface{}, len(messages))
+		for i, msg := range messages {
+			items[i] = []interface{}{msg.ID, msg.Values}
+		}
+		if err := emit(items); err != nil {
+			return err
+		}
+		if limit > 0 {
+			limit -= int64(len(messages))
+		}
+		last := messages[len(messages)-1].ID
+		if start, err = nextStreamID(last); err != nil || int64(len(messages)) < count {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextStreamID returns the smallest ID after id, since exclusive XRANGE
+// bounds need Redis 6.2.
+func nextStreamID(id string) (string, error) {
+	ms, seq, _ := strings.Cut(id, "-")
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("unexpected stream ID %q", id)
+	}
+	if n == ^uint64(0) {
+		t, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("unexpected stream ID %q", id)
+		}
+		return fmt.Sprintf("%d-0", t+1), nil
+	}
+	return fmt.Sprintf("%s-%d", ms, n+1), nil
+}
+
+// streamKeyNames streams KEYS pattern with SCAN, within the key prefix of the
+// connection.
+func streamKeyNames(c *gin.Context, client *redis.Client, id string, args []string, emit func([]interface{}) error) error {
+	if err := streamArgs("KEYS", args, 1, 1); err != nil {
+		return err
+	}
+	return forEachKeyBatch(c, client, scopePattern(id, args[0]), func(keys []string) error {
+		return emit(toInterfaces(keys))
+	})
+}
+
+func toInterfaces(items []string) []interface{} {
+	result := make([]interface{}, len(items))
+	for i, item := range items {
+		result[i] = item
+	}
+	return result
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// exportRecord is one line of an NDJSON export. Strings, elements and fields
+// are exported exactly as stored: text as plain JSON strings, binary data as
+// base64 {type: "binary"} objects. pttl is in milliseconds and -1 when the
+// key never expires.
+type exportRecord struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	PTTL  *int64      `json:"pttl"`
+	TTL   int64       `json:"ttl,omitempty"` // seconds, only in exports made before pttl
+	Value interface{} `json:"value"`
+}
+
+// exportKeys scans the database and writes every matching key to a temporary
+// NDJSON file as it goes, then serves the file as a download. Only one scan
+// batch is held in memory at a time, so exports of any size stay bounded.
+//
+// ?type= exports only keys of that type. Redis 6.0+ filters them with SCAN
+// TYPE; older servers scan every key and the others are dropped after their
+// TYPE lookup, which gives the same file but reads the whole keyspace.
+func exportKeys(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	pattern := c.DefaultQuery("pattern", "*")
+	keyType := c.Query("type")
+	scanType := ""
+	if keyType != "" {
+		server, _ := getConnection(id)
+		if info, err := serverInfoFor(c, id, server); err == nil && info.Capabilities.SupportsScanType {
+			scanType = keyType
+		}
+	}
+
+	file, err := os.CreateTemp("", "webredis-export-*.ndjson")
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to create export file: %v", err))
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	encoder := json.NewEncoder(out)
+	exported, skipped := 0, 0
+	err = forEachKeyBatchOfType(c, client, scopePattern(id, pattern), scanType, func(keys []string) error {
+		records, batchSkipped, err := readExportBatch(c, client, keys, keyType)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return fmt.Errorf("failed to write export file: %w", err)
+			}
+		}
+		exported += len(records)
+		skipped += batchSkipped
+		return nil
+	})
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	log.Printf("Exported %d keys (%d skipped) from connection %s db %s", exported, skipped, id, db)
+	c.Header("X-Export-Keys", strconv.Itoa(exported))
+	c.Header("X-Export-Skipped", strconv.Itoa(skipped))
+	filename := fmt.Sprintf("webredis-%s-db%s-%s.ndjson", id, db, time.Now().Format("20060102-150405"))
+	c.FileAttachment(file.Name(), filename)
+}
+
+// readExportBatch reads the type, TTL and value of keys in two pipelines. Keys
+// deleted since the scan and types webredis cannot display are skipped. With
+// a keyType, keys of other types are left out without counting as skipped.
+func readExportBatch(c *gin.Context, client *redis.Client, keys []string, keyType string) ([]exportRecord, int, error) {
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	pttlCmds := make([]*redis.DurationCmd, len(keys))
+	_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			typeCmds[i] = pipe.Type(c, key)
+			pttlCmds[i] = pipe.PTTL(c, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read key types: %w", err)
+	}
+
+	records := make([]exportRecord, 0, len(keys))
+	readers := make([]valueReader, 0, len(keys))
+	skipped := 0
+	pipe := client.Pipeline()
+	for i, key := range keys {
+		if keyType != "" && typeCmds[i].Val() != keyType {
+			continue
+		}
+		read := queueValueReadAs(c, pipe, key, typeCmds[i].Val(), rawValue)
+		if read == nil {
+			skipped++
+			continue
+		}
+		pttl := int64(-1)
+		if d := pttlCmds[i].Val(); d > 0 {
+			pttl = d.Milliseconds()
+		}
+		records = append(records, exportRecord{Key: key, Type: typeCmds[i].Val(), PTTL: &pttl})
+		readers = append(readers, read)
+	}
+	if len(readers) == 0 {
+		return records, skipped, nil
+	}
+
+	pipe.Exec(c)
+	kept := records[:0]
+	for i, read := range readers {
+		value, err := read()
+		if err != nil {
+			if isTimeout(err) || isUnreachable(err) {
+				return nil, 0, fmt.Errorf("failed to read keys: %w", err)
+			}
+			// Expired or replaced by another type since TYPE ran
+			skipped++
+			continue
+		}
+		records[i].Value = value
+		kept = append(kept, records[i])
+	}
+	return kept, skipped, nil
+}
+
+// streamKeys writes one NDJSON line per key matching ?pattern straight to the
+// response as the scan runs, flushing after every batch, so a client such as
+// `curl | jq` can process any keyspace while the server holds a single batch.
+// Lines carry key, type and pttl (milliseconds, -1 without expiry); ?values=true
+// adds the value like an export. Once streaming has started, a failure is
+// reported as a final line holding the error envelope of respondError. The scan stops when the client
+// disconnects.
+func streamKeys(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	pattern := scopePattern(id, c.DefaultQuery("pattern", "*"))
+	withValues := c.Query("values") == "true"
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	err := forEachKeyBatch(c, client, pattern, func(keys []string) error {
+		var records []exportRecord
+		if withValues {
+			var err error
+			records, _, err = readExportBatch(c, client, keys, "")
+			if err != nil {
+				return err
+			}
+		} else {
+			var err error
+			records, err = readKeyMetadata(c, client, keys)
+			if err != nil {
+				return err
+			}
+		}
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				// The client went away
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil && c.Err() == nil {
+		encoder.Encode(errorEnvelope(redisErrorCode(err), err.Error()))
+		c.Writer.Flush()
+	}
+}
+
+// readKeyMetadata returns the type and TTL of keys, leaving out the ones
+// deleted since they were scanned.
+func readKeyMetadata(c *gin.Context, client *redis.Client, keys []string) ([]exportRecord, error) {
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	pttlCmds := make([]*redis.DurationCmd, len(keys))
+	_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			typeCmds[i] = pipe.Type(c, key)
+			pttlCmds[i] = pipe.PTTL(c, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key types: %w", err)
+	}
+
+	records := make([]exportRecord, 0, len(keys))
+	for i, key := range keys {
+		keyType := typeCmds[i].Val()
+		if keyType == "none" {
+			continue
+		}
+		pttl := int64(-1)
+		if d := pttlCmds[i].Val(); d > 0 {
+			pttl = d.Milliseconds()
+		}
+		records = append(records, exportRecord{Key: key, Type: keyType, PTTL: &pttl})
+	}
+	return records, nil
+}
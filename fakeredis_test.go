@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis is a minimal RESP server for handler tests. reply gets every
+// command and returns its raw RESP reply; HELLO is refused, so clients fall
+// back to RESP2. Commands are recorded in the order they arrived.
+type fakeRedis struct {
+	addr  string
+	reply func(args []string) string
+
+	mu       sync.Mutex
+	commands [][]string
+}
+
+func newFakeRedis(t testing.TB, reply func(args []string) string) *fakeRedis {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	server := &fakeRedis{addr: ln.Addr().String(), reply: reply}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		// Commands arrive as arrays of bulk strings: *n, then $len and the
+		// argument for each of them
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		var n int
+		if _, err := fmt.Sscanf(header, "*%d", &n); err != nil {
+			return
+		}
+		args := make([]string, 0, n)
+		for i := 0; i < n; i++ {
+			if _, err := reader.ReadString('\n'); err != nil {
+				return
+			}
+			arg, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args = append(args, strings.TrimRight(arg, "\r\n"))
+		}
+		if len(args) > 0 && strings.EqualFold(args[0], "HELLO") {
+			conn.Write([]byte("-ERR unknown command 'HELLO'\r\n"))
+			continue
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, args)
+		s.mu.Unlock()
+		conn.Write([]byte(s.reply(args)))
+	}
+}
+
+// received returns the recorded commands named name, e.g. "PEXPIRE".
+func (s *fakeRedis) received(name string) [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matching [][]string
+	for _, args := range s.commands {
+		if strings.EqualFold(args[0], name) {
+			matching = append(matching, args)
+		}
+	}
+	return matching
+}
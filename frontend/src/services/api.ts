@@ -48,7 +48,9 @@ export const setKey = async (id: string, db: number, key: string, value: KeyValu
   const response = await api.post(`/key/${id}/${db}/${key}`, {
     type: value.type,
     value: value.value,
-    ttl: value.ttl || 0,
+    // Left out without a TTL, so the key keeps its expiry or gets the
+    // connection's default; 0 and -1 would remove it
+    ttl: value.ttl && value.ttl > 0 ? value.ttl : undefined,
   });
   return response.data;
 };
@@ -3,14 +3,32 @@ export interface RedisConnection {
   name: string;
   host: string;
   port: string;
-  password?: string;
+  password?: string; // only sent when creating, never returned
+  hasPassword?: boolean;
+  clearPassword?: boolean; // when saving an existing ID, drop its password
   db: number;
+  commandTimeout?: number;
+  clientName?: string;
+  protocol?: 2 | 3;
+  maxConcurrency?: number;
+  keyPrefix?: string;
+  noTouch?: boolean;
+  noEvict?: boolean;
+  defaultTTL?: number; // seconds
+  tags?: string[];
+  mode?: 'standalone' | 'cluster' | 'sentinel';
+  modeWarning?: string; // only returned when creating
+  status?: 'connected' | 'error' | 'skipped';
+  latencyMs?: number;
+  statusError?: string;
 }
 
 export interface KeyValue {
   type: string;
   value: any;
   ttl?: number;
+  // Hashes on Redis 7.4+: TTL of the fields that expire
+  fieldTtls?: Record<string, { ttl: number; pttl: number; ttlHuman: string }>;
 }
 
 export interface DatabaseInfo {
@@ -30,5 +48,9 @@ export interface ConnectionState {
 export interface KeyInfo {
   key: string;
   ttl: number;
+  pttl?: number;
+  ttlHuman?: string;
+  persistent?: boolean;
+  missing?: boolean;
   type: string;
 } 
\ No newline at end of file
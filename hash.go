@@ -0,0 +1,378 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// hashScanMaxCount caps the COUNT hint of one hash field page.
+const hashScanMaxCount = 1000
+
+// listHashFields returns one HSCAN page of a hash, starting at ?cursor (0 for
+// the first page) and optionally filtered by ?match. With
+// ?valuesRequired=false only field names are returned, using HSCAN NOVALUES
+// on Redis 7.4+ so big values never leave the server.
+func listHashFields(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	cursor, err := strconv.ParseUint(c.DefaultQuery("cursor", "0"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid cursor")
+		return
+	}
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid count")
+		return
+	}
+	count = min(count, hashScanMaxCount)
+	match := c.Query("match")
+	valuesRequired := c.Query("valuesRequired") != "false"
+
+	noValues := false
+	if !valuesRequired {
+		server, _ := getConnection(id)
+		if info, err := serverInfoFor(c, id, server); err == nil {
+			noValues = info.Capabilities.SupportsHscanNovalues
+		}
+	}
+
+	args := []interface{}{"HSCAN", key, cursor}
+	if match != "" {
+		args = append(args, "MATCH", match)
+	}
+	args = append(args, "COUNT", count)
+	if noValues {
+		args = append(args, "NOVALUES")
+	}
+	reply, err := client.Do(c, args...).Slice()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to scan hash fields: %v", err))
+		return
+	}
+	if len(reply) != 2 {
+		respondError(c, http.StatusInternalServerError, errInternal, "Unexpected HSCAN reply")
+		return
+	}
+	items, _ := reply[1].([]interface{})
+
+	// The cursor is returned as a string, it may not fit a JavaScript number
+	result := gin.H{"cursor": fmt.Sprint(reply[0])}
+	if noValues {
+		result["fields"] = replyStrings(items)
+	} else if !valuesRequired {
+		// Older server: the values came along and are dropped here
+		fields := make([]string, 0, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			fields = append(fields, fmt.Sprint(items[i]))
+		}
+		result["fields"] = fields
+	} else {
+		entries := make([]gin.H, 0, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			entries = append(entries, gin.H{
+				"field": fmt.Sprint(items[i]),
+				"value": decodeValue(fmt.Sprint(items[i+1])),
+			})
+		}
+		result["entries"] = entries
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func setHashField(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	field := c.Param("field")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Value        interface{} `json:"value"`
+		OnlyIfAbsent bool        `json:"onlyIfAbsent"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	value, err := stringValue(data.Value)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Failed to convert value to string")
+		return
+	}
+
+	var created bool
+	if data.OnlyIfAbsent {
+		// HSETNX leaves an existing field untouched
+		created, err = client.HSetNX(c, key, field, value).Result()
+	} else {
+		var added int64
+		added, err = client.HSet(c, key, field, value).Result()
+		created = added == 1
+	}
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to set hash field: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"field":   field,
+		"created": created,
+	})
+}
+
+func deleteHashField(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	field := c.Param("field")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	removed, err := client.HDel(c, key, field).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to delete hash field: %v", err))
+		return
+	}
+	if removed == 0 {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Field '%s' does not exist", field))
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// requireHashFieldTTL responds 501 unless the server supports per-field hash
+// expiry (HEXPIRE, HPTTL, HPERSIST), added in Redis 7.4.
+func requireHashFieldTTL(c *gin.Context, id string) bool {
+	server, _ := getConnection(id)
+	info, err := serverInfoFor(c, id, server)
+	if err == nil && !info.Capabilities.SupportsHashFieldTTL {
+		respondError(c, http.StatusNotImplemented, errNotSupported, fmt.Sprintf("Hash field expiry requires Redis 7.4 or newer, the server runs %s", info.Version))
+		return false
+	}
+	return true
+}
+
+// hashFieldArgs appends the "FIELDS numfields field..." block of the hash
+// field expiry commands to args.
+func hashFieldArgs(args []interface{}, fields []string) []interface{} {
+	args = append(args, "FIELDS", len(fields))
+	for _, field := range fields {
+		args = append(args, field)
+	}
+	return args
+}
+
+// readHashFieldTTLs returns the HPTTL reply of each field: milliseconds left,
+// -1 for a field without expiry and -2 for a missing one.
+func readHashFieldTTLs(ctx context.Context, client *redis.Client, key string, fields []string) (map[string]int64, error) {
+	ttls := make(map[string]int64, len(fields))
+	if len(fields) == 0 {
+		return ttls, nil
+	}
+	reply, err := client.Do(ctx, hashFieldArgs([]interface{}{"HPTTL", key}, fields)...).Int64Slice()
+	if errors.Is(err, redis.Nil) {
+		// The whole key is gone
+		for _, field := range fields {
+			ttls[field] = -2
+		}
+		return ttls, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range fields {
+		if i < len(reply) {
+			ttls[field] = reply[i]
+		}
+	}
+	return ttls, nil
+}
+
+// fieldTTLInfo describes an HPTTL value with the same fields as a key TTL.
+func fieldTTLInfo(pttl int64) gin.H {
+	info := gin.H{}
+	if pttl < 0 {
+		addTTLFields(info, time.Duration(pttl))
+	} else {
+		addTTLFields(info, time.Duration(pttl)*time.Millisecond)
+	}
+	return info
+}
+
+// getHashFieldTTLs returns the expiry of the ?field fields of a hash, or of
+// every field when none is given.
+func getHashFieldTTLs(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+	if !requireHashFieldTTL(c, id) {
+		return
+	}
+
+	fields := c.QueryArray("field")
+	if len(fields) == 0 {
+		var err error
+		fields, err = client.HKeys(c, key).Result()
+		if err != nil {
+			if isWrongType(err) {
+				respondWrongType(c, client, key, "hash")
+				return
+			}
+			respondRedisError(c, err, fmt.Sprintf("Failed to read hash fields: %v", err))
+			return
+		}
+	}
+
+	ttls, err := readHashFieldTTLs(c, client, key, fields)
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read field TTLs: %v", err))
+		return
+	}
+
+	result := make(map[string]gin.H, len(ttls))
+	for field, pttl := range ttls {
+		result[field] = fieldTTLInfo(pttl)
+	}
+	c.JSON(http.StatusOK, gin.H{"fields": result})
+}
+
+// setHashFieldTTL sets the expiry of one hash field with HEXPIRE. ttl is in
+// seconds; option (nx, xx, gt or lt) makes it conditional as for keys.
+func setHashFieldTTL(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	field := c.Param("field")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+	if !requireHashFieldTTL(c, id) {
+		return
+	}
+
+	var data struct {
+		TTL    int64  `json:"ttl"`
+		Option string `json:"option"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.TTL <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ttl must be a positive number of seconds")
+		return
+	}
+
+	args := []interface{}{"HEXPIRE", key, data.TTL}
+	switch option := strings.ToLower(data.Option); option {
+	case "":
+	case "nx", "xx", "gt", "lt":
+		args = append(args, strings.ToUpper(option))
+	default:
+		respondError(c, http.StatusBadRequest, errBadRequest, "option must be nx, xx, gt or lt")
+		return
+	}
+
+	reply, err := client.Do(c, hashFieldArgs(args, []string{field})...).Int64Slice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "Hash field expiry requires Redis 7.4 or newer")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to set field TTL: %v", err))
+		return
+	}
+	// -2 means no such field (or key), 0 that the option condition failed
+	if len(reply) == 0 || reply[0] == -2 {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Field '%s' does not exist", field))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changed": reply[0] == 1})
+}
+
+// persistHashField removes the expiry of one hash field with HPERSIST.
+func persistHashField(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	field := c.Param("field")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+	if !requireHashFieldTTL(c, id) {
+		return
+	}
+
+	reply, err := client.Do(c, hashFieldArgs([]interface{}{"HPERSIST", key}, []string{field})...).Int64Slice()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "hash")
+			return
+		}
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "Hash field expiry requires Redis 7.4 or newer")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to persist field: %v", err))
+		return
+	}
+	// -2 means no such field (or key), -1 that it had no expiry
+	if len(reply) == 0 || reply[0] == -2 {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Field '%s' does not exist", field))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changed": reply[0] == 1})
+}
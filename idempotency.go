@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idempotencyTTL is how long the response of an Idempotency-Key is replayed.
+var idempotencyTTL = envDuration("WEBREDIS_IDEMPOTENCY_TTL", 24*time.Hour)
+
+// idempotencyMaxBody is the largest response body recorded for a key; bigger
+// responses are not stored and a retry runs again.
+const idempotencyMaxBody = 1 << 20
+
+// idempotencyInFlight holds the keys of requests still running, so a retry
+// sent before the first attempt finished is rejected instead of running twice.
+var (
+	idempotencyInFlight   = make(map[string]bool)
+	idempotencyInFlightMu sync.Mutex
+)
+
+// recordingWriter keeps a copy of the response body while writing it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.record(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(data []byte) {
+	if w.overflow || w.body.Len()+len(data) > idempotencyMaxBody {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+// idempotency is the middleware replaying the stored response of a POST, PUT
+// or DELETE sent again with the same Idempotency-Key header, without running
+// the handler a second time. Only successful (2xx) responses are stored, so
+// a request that failed, e.g. on a missing key or a server error, can be
+// retried for real. Reusing a key for a different method or path is a 409.
+func idempotency(c *gin.Context) {
+	key := c.GetHeader("Idempotency-Key")
+	method := c.Request.Method
+	if key == "" || (method != http.MethodPost && method != http.MethodPut && method != http.MethodDelete) {
+		c.Next()
+		return
+	}
+	if len(key) > 255 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Idempotency-Key must be at most 255 characters")
+		c.Abort()
+		return
+	}
+
+	// The key is reserved before the stored response is read: a retry racing
+	// the end of the first attempt then gets either the 409 below or the
+	// stored response, and never runs the handler again
+	idempotencyInFlightMu.Lock()
+	if idempotencyInFlight[key] {
+		idempotencyInFlightMu.Unlock()
+		respondError(c, http.StatusConflict, errConflict, "A request with this Idempotency-Key is still running")
+		c.Abort()
+		return
+	}
+	idempotencyInFlight[key] = true
+	idempotencyInFlightMu.Unlock()
+	defer func() {
+		idempotencyInFlightMu.Lock()
+		delete(idempotencyInFlight, key)
+		idempotencyInFlightMu.Unlock()
+	}()
+
+	stored, err := getIdempotentResponse(key, time.Now().Add(-idempotencyTTL))
+	switch {
+	case err == nil:
+		if stored.Method != c.Request.Method || stored.Path != c.Request.URL.Path {
+			respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("Idempotency-Key was already used for %s %s", stored.Method, stored.Path))
+			c.Abort()
+			return
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(stored.Status, stored.ContentType, stored.Body)
+		c.Abort()
+		return
+	case !errors.Is(err, sql.ErrNoRows):
+		// Running the request is safer than failing it when only the
+		// bookkeeping is broken
+		log.Printf("Warning: Failed to read idempotency key: %v", err)
+	}
+
+	writer := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = writer
+	c.Next()
+
+	status := writer.Status()
+	if status < 200 || status >= 300 || writer.overflow {
+		return
+	}
+	resp := storedResponse{
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		Status:      status,
+		ContentType: writer.Header().Get("Content-Type"),
+		// An empty body is nil, which the NOT NULL column refuses
+		Body: append([]byte{}, writer.body.Bytes()...),
+	}
+	if err := saveIdempotentResponse(key, resp, time.Now().Add(-idempotencyTTL)); err != nil {
+		log.Printf("Warning: Failed to save idempotency key: %v", err)
+	}
+}
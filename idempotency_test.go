@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newIdempotencyRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(idempotency)
+	router.POST("/key", handler)
+	router.DELETE("/key", handler)
+	return router
+}
+
+func sendWithKey(router http.Handler, method, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/key", nil)
+	req.Header.Set("Idempotency-Key", key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysSuccess(t *testing.T) {
+	useTestDB(t)
+	var runs atomic.Int32
+	router := newIdempotencyRouter(func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"run": runs.Add(1)})
+	})
+
+	first := sendWithKey(router, http.MethodPost, "replay")
+	second := sendWithKey(router, http.MethodPost, "replay")
+	if runs.Load() != 1 {
+		t.Fatalf("handler ran %d times, want 1", runs.Load())
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry got %d %q, want the replayed %q", second.Code, second.Body.String(), first.Body.String())
+	}
+
+	if w := sendWithKey(router, http.MethodDelete, "replay"); w.Code != http.StatusConflict {
+		t.Errorf("key reused for another method got %d, want 409", w.Code)
+	}
+}
+
+func TestIdempotencyDoesNotStoreFailures(t *testing.T) {
+	useTestDB(t)
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError} {
+		var runs atomic.Int32
+		router := newIdempotencyRouter(func(c *gin.Context) {
+			runs.Add(1)
+			respondError(c, status, errBadRequest, "failed")
+		})
+		key := http.StatusText(status)
+		sendWithKey(router, http.MethodPost, key)
+		if w := sendWithKey(router, http.MethodPost, key); w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("%d response was replayed", status)
+		}
+		if runs.Load() != 2 {
+			t.Errorf("handler ran %d times after a %d, want 2", runs.Load(), status)
+		}
+	}
+}
+
+func TestIdempotencyConcurrentRetriesRunOnce(t *testing.T) {
+	useTestDB(t)
+	var runs atomic.Int32
+	router := newIdempotencyRouter(func(c *gin.Context) {
+		runs.Add(1)
+		c.Status(http.StatusOK)
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := sendWithKey(router, http.MethodPost, "concurrent")
+			if w.Code != http.StatusOK && w.Code != http.StatusConflict {
+				t.Errorf("retry got %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	if runs.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", runs.Load())
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// importBatchSize is how many records are written per pipeline.
+const importBatchSize = 500
+
+// importMaxLineBytes caps a single NDJSON record, i.e. the largest key an
+// import can restore.
+var importMaxLineBytes = envInt("WEBREDIS_IMPORT_MAX_LINE_BYTES", 64<<20)
+
+type importSummary struct {
+	Imported int      `json:"imported"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// maxImportErrors caps how many per-line errors are reported back.
+const maxImportErrors = 100
+
+func (s *importSummary) skip(line int, reason string) {
+	s.Skipped++
+	if len(s.Errors) < maxImportErrors {
+		s.Errors = append(s.Errors, fmt.Sprintf("line %d: %s", line, reason))
+	}
+}
+
+// importKeys restores an NDJSON export. The body is read line by line and
+// written in pipelined batches, so dumps of any size can be imported. Existing
+// keys are left alone unless ?replace=true. Clients that accept
+// text/event-stream receive a progress event after every batch.
+func importKeys(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	// Overwriting existing keys is destructive, plain restores are not
+	replace := c.Query("replace") == "true"
+	if replace && !requireAdmin(c) {
+		return
+	}
+
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, _, err := c.Request.FormFile("file")
+		if err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid upload: %v", err))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	stream := strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+	if stream {
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), importMaxLineBytes)
+
+	var summary importSummary
+	batch := make([]exportRecord, 0, importBatchSize)
+	lines := make([]int, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := writeImportBatch(c, client, batch, lines, replace, &summary); err != nil {
+			return err
+		}
+		batch, lines = batch[:0], lines[:0]
+		if stream {
+			c.SSEvent("progress", summary)
+			c.Writer.Flush()
+		}
+		return nil
+	}
+
+	line := 0
+	var err error
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var record exportRecord
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			summary.skip(line, fmt.Sprintf("invalid JSON: %v", err))
+			continue
+		}
+		if record.Key == "" {
+			summary.skip(line, "missing key")
+			continue
+		}
+		if !keyInScope(id, record.Key) {
+			summary.skip(line, fmt.Sprintf("key '%s' is outside the connection's key prefix", record.Key))
+			continue
+		}
+		batch = append(batch, record)
+		lines = append(lines, line)
+		if len(batch) == importBatchSize {
+			if err = flush(); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = scanner.Err()
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("line %d is longer than %d bytes", line+1, importMaxLineBytes)
+		}
+	}
+	if err == nil {
+		err = flush()
+	}
+
+	invalidateKeyCount(id, db)
+	log.Printf("Imported %d keys (%d skipped) into connection %s db %s", summary.Imported, summary.Skipped, id, db)
+	if stream {
+		// The status line is already sent, so failures travel as an event
+		if err != nil {
+			c.SSEvent("error", gin.H{"message": err.Error(), "summary": summary})
+		} else {
+			c.SSEvent("done", summary)
+		}
+		return
+	}
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Import stopped after %d keys: %v", summary.Imported, err))
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// writeImportBatch writes a batch of records in one pipeline. Without
+// replace, keys that already exist are skipped.
+func writeImportBatch(c *gin.Context, client *redis.Client, records []exportRecord, lines []int, replace bool, summary *importSummary) error {
+	exists := make([]*redis.IntCmd, len(records))
+	if !replace {
+		_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, record := range records {
+				exists[i] = pipe.Exists(c, record.Key)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check existing keys: %w", err)
+		}
+	}
+
+	written := 0
+	pipe := client.Pipeline()
+	for i, record := range records {
+		if !replace && exists[i].Val() > 0 {
+			summary.skip(lines[i], fmt.Sprintf("key '%s' already exists", record.Key))
+			continue
+		}
+		// Exports with pttl hold the raw stored strings; older ones held
+		// decoded JSON, which is written back as before
+		encode := rawString
+		if record.PTTL == nil {
+			encode = encodeValue
+		}
+		if err := queueValueWriteAs(c, pipe, record.Key, record.Type, record.Value, encode); err != nil {
+			summary.skip(lines[i], err.Error())
+			continue
+		}
+		switch {
+		case record.PTTL != nil && *record.PTTL > 0:
+			pipe.PExpire(c, record.Key, time.Duration(*record.PTTL)*time.Millisecond)
+		case record.PTTL == nil && record.TTL > 0:
+			pipe.Expire(c, record.Key, time.Duration(record.TTL)*time.Second)
+		}
+		written++
+	}
+	if written == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(c); err != nil {
+		return fmt.Errorf("failed to write keys: %w", err)
+	}
+	summary.Imported += written
+	return nil
+}
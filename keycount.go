@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyCountTTL is how long a cached key count is served as fresh. Older counts
+// are still returned immediately, marked stale, while a refresh runs in the
+// background.
+var keyCountTTL = envDuration("WEBREDIS_KEY_COUNT_TTL", 10*time.Second)
+
+type keyCount struct {
+	count      int64
+	fetchedAt  time.Time
+	refreshing bool
+}
+
+// keyCounts caches key counts per "<id>/<db>", like dbClients.
+var (
+	keyCounts   = make(map[string]*keyCount)
+	keyCountsMu sync.Mutex
+)
+
+// keyCountKey returns the cache key of a database, with db normalized like
+// in clientForDB so "01" and "1" share an entry.
+func keyCountKey(id, db string) string {
+	if index, err := strconv.Atoi(db); err == nil {
+		return fmt.Sprintf("%s/%d", id, index)
+	}
+	return id + "/" + db
+}
+
+// getKeyCount serves the number of keys in a database from the cache. The
+// first request for a database waits for DBSIZE; later ones never do. On a
+// connection with a key prefix only the keys under it are counted, with
+// countKeysInScope.
+func getKeyCount(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	cacheKey := keyCountKey(id, db)
+	keyCountsMu.Lock()
+	entry, cached := keyCounts[cacheKey]
+	if cached {
+		count, fetchedAt := entry.count, entry.fetchedAt
+		stale := time.Since(fetchedAt) >= keyCountTTL
+		if stale && !entry.refreshing {
+			entry.refreshing = true
+			go refreshKeyCount(id, cacheKey)
+		}
+		keyCountsMu.Unlock()
+
+		c.JSON(http.StatusOK, gin.H{
+			"count":     count,
+			"stale":     stale,
+			"fetchedAt": fetchedAt,
+		})
+		return
+	}
+	keyCountsMu.Unlock()
+
+	count, err := countKeysInScope(c, client, id)
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+	fetchedAt := time.Now()
+	keyCountsMu.Lock()
+	keyCounts[cacheKey] = &keyCount{count: count, fetchedAt: fetchedAt}
+	keyCountsMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":     count,
+		"stale":     false,
+		"fetchedAt": fetchedAt,
+	})
+}
+
+// refreshKeyCount re-counts the keys of a cache entry outside of any request.
+// It uses the shared per-database client, since the one handed to the request
+// may be closed when the request ends.
+func refreshKeyCount(id, cacheKey string) {
+	dbClientsMu.Lock()
+	client, ok := dbClients[cacheKey]
+	dbClientsMu.Unlock()
+
+	var (
+		count int64
+		err   = redis.ErrClosed
+	)
+	if ok {
+		// Counting under a key prefix scans the database, which takes longer
+		// than one DBSIZE
+		timeout := statusPingTimeout
+		if keyPrefixFor(id) != "" {
+			timeout = maxRequestTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		count, err = countKeysInScope(ctx, client, id)
+		cancel()
+	}
+
+	keyCountsMu.Lock()
+	defer keyCountsMu.Unlock()
+	entry, ok := keyCounts[cacheKey]
+	if !ok {
+		// Invalidated while the refresh ran
+		return
+	}
+	entry.refreshing = false
+	if err != nil {
+		log.Printf("Warning: Failed to refresh key count for %s: %v", cacheKey, err)
+		return
+	}
+	entry.count = count
+	entry.fetchedAt = time.Now()
+}
+
+// adjustKeyCount applies a known change, e.g. -1 after a successful DEL, to a
+// cached count so it stays accurate until the next refresh.
+func adjustKeyCount(id, db string, delta int64) {
+	keyCountsMu.Lock()
+	defer keyCountsMu.Unlock()
+	if entry, ok := keyCounts[keyCountKey(id, db)]; ok {
+		entry.count = max(entry.count+delta, 0)
+	}
+}
+
+// invalidateKeyCount drops the cached count of a database after a write whose
+// effect on the count is unknown, such as setKey possibly creating a key.
+func invalidateKeyCount(id, db string) {
+	keyCountsMu.Lock()
+	delete(keyCounts, keyCountKey(id, db))
+	keyCountsMu.Unlock()
+}
+
+// forgetKeyCounts drops every cached count of a connection.
+func forgetKeyCounts(id string) {
+	keyCountsMu.Lock()
+	defer keyCountsMu.Unlock()
+	prefix := id + "/"
+	for cacheKey := range keyCounts {
+		if strings.HasPrefix(cacheKey, prefix) {
+			delete(keyCounts, cacheKey)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type keyCountResponse struct {
+	Count int64 `json:"count"`
+	Stale bool  `json:"stale"`
+}
+
+func TestGetKeyCountStaleness(t *testing.T) {
+	previous := keyCountTTL
+	keyCountTTL = 50 * time.Millisecond
+	defer func() { keyCountTTL = previous }()
+
+	var dbsize atomic.Int64
+	dbsize.Store(10)
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] == "dbsize" {
+			return fmt.Sprintf(":%d\r\n", dbsize.Load())
+		}
+		return "+OK\r\n"
+	})
+	const id = "test-keycount"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/keys/:id/:db/count", getKeyCount)
+	get := func(db string) keyCountResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/keys/"+id+"/"+db+"/count", nil))
+		var resp keyCountResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%d %s: %v", w.Code, w.Body.String(), err)
+		}
+		return resp
+	}
+
+	if resp := get("0"); resp.Count != 10 || resp.Stale {
+		t.Fatalf("first count = %+v, want a fresh 10", resp)
+	}
+	dbsize.Store(20)
+	// "00" is the same database and shares the cached entry
+	if resp := get("00"); resp.Count != 10 || resp.Stale {
+		t.Errorf("count within the TTL = %+v, want the cached 10", resp)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if resp := get("0"); resp.Count != 10 || !resp.Stale {
+		t.Errorf("count after the TTL = %+v, want a stale 10", resp)
+	}
+	// The stale read started a refresh in the background
+	deadline := time.Now().Add(time.Second)
+	for get("0").Count != 20 {
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not update the count")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	adjustKeyCount(id, "0", -5)
+	if resp := get("0"); resp.Count != 15 {
+		t.Errorf("count after adjusting by -5 = %d, want 15", resp.Count)
+	}
+	dbsize.Store(30)
+	invalidateKeyCount(id, "00")
+	if resp := get("0"); resp.Count != 30 || resp.Stale {
+		t.Errorf("count after invalidation = %+v, want a fresh 30", resp)
+	}
+}
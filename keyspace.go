@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyspaceMaxSubscriptions caps the filtered subscriptions of one keyspace
+// feed socket.
+var keyspaceMaxSubscriptions = envInt("WEBREDIS_KEYSPACE_MAX_SUBSCRIPTIONS", 20)
+
+// keyspaceControl is a message sent by the client over a keyspace feed
+// socket: {"op": "subscribe", "id": "s1", "pattern": "user:*", "events":
+// ["set", "del"]} or {"op": "unsubscribe", "id": "s1"}.
+type keyspaceControl struct {
+	Op      string   `json:"op"`
+	ID      string   `json:"id"`
+	Pattern string   `json:"pattern"`
+	Events  []string `json:"events"`
+}
+
+// keyspaceSubscription is one filtered subscription of a feed. Keys are
+// filtered by Redis through the channel pattern, events here.
+type keyspaceSubscription struct {
+	id      string
+	channel string          // "__keyspace@<db>__:<pattern>"
+	events  map[string]bool // empty forwards every event
+}
+
+type keyspaceEvent struct {
+	Type         string    `json:"type"`
+	Subscription string    `json:"subscription"`
+	Key          string    `json:"key"`
+	Event        string    `json:"event"`
+	Time         time.Time `json:"time"`
+}
+
+// keyspaceFeed streams keyspace notifications of one database over a
+// WebSocket, filtered by key pattern and event type before they are sent.
+// ?pattern and ?events=set,del open a first subscription with the id
+// "default"; more are added and removed with keyspaceControl messages, each
+// acknowledged with {"type": "subscribed" | "unsubscribed", "id"} or
+// answered with {"type": "error"}. Matching notifications are sent as
+// {"type": "event", "subscription", "key", "event", "time"}, once per
+// matching subscription. Like the changelog, this needs
+// notify-keyspace-events to include K and the event classes of interest.
+func keyspaceFeed(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	if _, ok := clientForDB(c, id, db); !ok {
+		return
+	}
+	// Channels name the database by number, so "01" must become 1
+	index, _ := strconv.Atoi(db)
+	base, _ := getConnection(id)
+
+	// CONFIG may be disabled on managed servers, then the feed is started
+	// anyway and stays silent if notifications are off
+	if config, err := base.ConfigGet(c, "notify-keyspace-events").Result(); err == nil {
+		if flags := config["notify-keyspace-events"]; !strings.Contains(flags, "K") {
+			respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("Keyspace notifications are disabled (notify-keyspace-events is '%s'); set it to include K and the event classes, e.g. 'KA'", flags))
+			return
+		}
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade keyspace feed connection: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pubsub := base.PSubscribe(ctx)
+	defer pubsub.Close()
+
+	// Control messages are applied by the loop below, which owns the
+	// subscriptions and is the only writer to the socket
+	controls := make(chan keyspaceControl)
+	go func() {
+		defer cancel()
+		for {
+			_, data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			// Anything that is not a control message gets the unknown op error
+			var control keyspaceControl
+			json.Unmarshal(data, &control)
+			select {
+			case controls <- control:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	feed := &keyspaceFeedState{ctx: ctx, ws: ws, pubsub: pubsub, id: id, index: index, subscriptions: make(map[string]*keyspaceSubscription)}
+	if c.Query("pattern") != "" || c.Query("events") != "" {
+		var events []string
+		if list := c.Query("events"); list != "" {
+			events = strings.Split(list, ",")
+		}
+		if !feed.apply(keyspaceControl{Op: "subscribe", ID: "default", Pattern: c.Query("pattern"), Events: events}) {
+			return
+		}
+	}
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case control := <-controls:
+			if !feed.apply(control) {
+				return
+			}
+		case msg, ok := <-messages:
+			if !ok {
+				return
+			}
+			if !feed.forward(msg) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+type keyspaceFeedState struct {
+	ctx           context.Context
+	ws            *websocket.Conn
+	pubsub        *redis.PubSub
+	id            string
+	index         int // database number
+	subscriptions map[string]*keyspaceSubscription
+}
+
+// apply runs one control message and acknowledges it. It returns false once
+// the socket cannot be written to.
+func (feed *keyspaceFeedState) apply(control keyspaceControl) bool {
+	if err := feed.run(control); err != nil {
+		return feed.ws.WriteJSON(gin.H{"type": "error", "id": control.ID, "error": err.Error()}) == nil
+	}
+	return feed.ws.WriteJSON(gin.H{"type": control.Op + "d", "id": control.ID}) == nil
+}
+
+func (feed *keyspaceFeedState) run(control keyspaceControl) error {
+	switch control.Op {
+	case "subscribe":
+		if control.ID == "" {
+			return fmt.Errorf("id is required")
+		}
+		if feed.subscriptions[control.ID] != nil {
+			return fmt.Errorf("subscription '%s' already exists", control.ID)
+		}
+		if len(feed.subscriptions) >= keyspaceMaxSubscriptions {
+			return fmt.Errorf("at most %d subscriptions per feed", keyspaceMaxSubscriptions)
+		}
+		pattern := control.Pattern
+		if pattern == "" {
+			pattern = "*"
+		}
+		subscription := &keyspaceSubscription{
+			id:      control.ID,
+			channel: keyspaceChannel(feed.index, scopePattern(feed.id, pattern)),
+			events:  make(map[string]bool),
+		}
+		for _, event := range control.Events {
+			if event = strings.TrimSpace(event); event != "" {
+				subscription.events[strings.ToLower(event)] = true
+			}
+		}
+		// Subscriptions with the same pattern share one PSUBSCRIBE
+		if !feed.channelInUse(subscription.channel) {
+			if err := feed.pubsub.PSubscribe(feed.ctx, subscription.channel); err != nil {
+				return fmt.Errorf("failed to subscribe: %v", err)
+			}
+		}
+		feed.subscriptions[control.ID] = subscription
+		return nil
+	case "unsubscribe":
+		subscription := feed.subscriptions[control.ID]
+		if subscription == nil {
+			return fmt.Errorf("no subscription '%s'", control.ID)
+		}
+		delete(feed.subscriptions, control.ID)
+		if !feed.channelInUse(subscription.channel) {
+			if err := feed.pubsub.PUnsubscribe(feed.ctx, subscription.channel); err != nil {
+				return fmt.Errorf("failed to unsubscribe: %v", err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown op '%s', expected {\"op\": \"subscribe\" | \"unsubscribe\", \"id\": ...}", control.Op)
+	}
+}
+
+func (feed *keyspaceFeedState) channelInUse(channel string) bool {
+	for _, subscription := range feed.subscriptions {
+		if subscription.channel == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// keyOf returns the key a keyspace notification channel of the feed's
+// database is about.
+func (feed *keyspaceFeedState) keyOf(channel string) string {
+	return strings.TrimPrefix(channel, keyspaceChannel(feed.index, ""))
+}
+
+// forward sends a notification to every subscription whose pattern and event
+// filter it matches. It returns false once the socket cannot be written to.
+func (feed *keyspaceFeedState) forward(msg *redis.Message) bool {
+	key := feed.keyOf(msg.Channel)
+	for _, subscription := range feed.subscriptions {
+		if subscription.channel != msg.Pattern {
+			continue
+		}
+		if len(subscription.events) > 0 && !subscription.events[msg.Payload] {
+			continue
+		}
+		event := keyspaceEvent{
+			Type:         "event",
+			Subscription: subscription.id,
+			Key:          key,
+			Event:        msg.Payload,
+			Time:         time.Now(),
+		}
+		if err := feed.ws.WriteJSON(event); err != nil {
+			return false
+		}
+	}
+	return true
+}
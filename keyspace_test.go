@@ -0,0 +1,14 @@
+package main
+
+import "testing"
+
+func TestKeyspaceFeedKeyOf(t *testing.T) {
+	// A feed opened on /keyspace/:id/01 watches database 1
+	feed := &keyspaceFeedState{id: "test", index: 1}
+	if got := feed.keyOf("__keyspace@1__:orders:42"); got != "orders:42" {
+		t.Errorf("keyOf() = %q, want orders:42", got)
+	}
+	if got := keyspaceChannel(feed.index, "orders:*"); got != "__keyspace@1__:orders:*" {
+		t.Errorf("subscription channel = %q", got)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type lcsRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type lcsMatch struct {
+	Key1     lcsRange `json:"key1"`
+	Key2     lcsRange `json:"key2"`
+	MatchLen int64    `json:"matchLen,omitempty"`
+}
+
+// longestCommonSubsequence runs LCS (Redis 7.0+) on two string keys. By
+// default it returns the common subsequence itself; "len" returns only its
+// length, and "idx" the matching ranges in both keys, longest first, filtered
+// by "minMatchLen" and with each range's length when "withMatchLen" is set.
+// Ranges are inclusive byte offsets.
+func longestCommonSubsequence(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Key1         string `json:"key1"`
+		Key2         string `json:"key2"`
+		Len          bool   `json:"len"`
+		Idx          bool   `json:"idx"`
+		MinMatchLen  int    `json:"minMatchLen"`
+		WithMatchLen bool   `json:"withMatchLen"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Key1 == "" || data.Key2 == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "key1 and key2 are required")
+		return
+	}
+	if data.Len && data.Idx {
+		respondError(c, http.StatusBadRequest, errBadRequest, "len and idx are mutually exclusive")
+		return
+	}
+	if !data.Idx && (data.MinMatchLen != 0 || data.WithMatchLen) {
+		respondError(c, http.StatusBadRequest, errBadRequest, "minMatchLen and withMatchLen need idx")
+		return
+	}
+	if data.MinMatchLen < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "minMatchLen must not be negative")
+		return
+	}
+	if !requireKeysInScope(c, id, data.Key1, data.Key2) {
+		return
+	}
+
+	result, err := client.LCS(c, &redis.LCSQuery{
+		Key1:         data.Key1,
+		Key2:         data.Key2,
+		Len:          data.Len,
+		Idx:          data.Idx,
+		MinMatchLen:  data.MinMatchLen,
+		WithMatchLen: data.WithMatchLen,
+	}).Result()
+	if err != nil {
+		switch {
+		case isUnsupportedCommand(err):
+			respondError(c, http.StatusNotImplemented, errNotSupported, "LCS requires Redis 7.0 or newer")
+		case isWrongType(err):
+			respondError(c, http.StatusConflict, errWrongType, "LCS needs two string keys")
+		default:
+			respondRedisError(c, err, fmt.Sprintf("Failed to run LCS: %v", err))
+		}
+		return
+	}
+
+	switch {
+	case data.Len:
+		c.JSON(http.StatusOK, gin.H{"len": result.Len})
+	case data.Idx:
+		matches := make([]lcsMatch, len(result.Matches))
+		for i, m := range result.Matches {
+			matches[i] = lcsMatch{
+				Key1:     lcsRange{Start: m.Key1.Start, End: m.Key1.End},
+				Key2:     lcsRange{Start: m.Key2.Start, End: m.Key2.End},
+				MatchLen: m.MatchLen,
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"len": result.Len, "matches": matches})
+	default:
+		c.JSON(http.StatusOK, gin.H{"match": result.MatchString, "len": len(result.MatchString)})
+	}
+}
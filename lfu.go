@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// lfuSampleSize is the default and maximum number of keys sampled by the LFU
+// report.
+var lfuSampleSize = envInt("WEBREDIS_LFU_SAMPLE_SIZE", 10000)
+
+type keyFrequency struct {
+	Key  string `json:"key"`
+	Freq int64  `json:"freq"`
+}
+
+// getLFUBottom samples keys with SCAN and returns the ones with the lowest
+// OBJECT FREQ counter, i.e. the next candidates for LFU eviction. The counter
+// is only maintained under an LFU maxmemory-policy.
+func getLFUBottom(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "50"))
+	if err != nil || count <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid count")
+		return
+	}
+	sample := lfuSampleSize
+	if s := c.Query("sample"); s != "" {
+		sample, err = strconv.Atoi(s)
+		if err != nil || sample <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid sample")
+			return
+		}
+		if sample > lfuSampleSize {
+			sample = lfuSampleSize
+		}
+	}
+
+	policy, err := cachedReply(c, id, "CONFIG GET maxmemory-policy", func() (map[string]string, error) {
+		return client.ConfigGet(c, "maxmemory-policy").Result()
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read maxmemory-policy: %v", err))
+		return
+	}
+	if !strings.Contains(policy["maxmemory-policy"], "lfu") {
+		respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("maxmemory-policy is '%s'; OBJECT FREQ is only available with allkeys-lfu or volatile-lfu", policy["maxmemory-policy"]))
+		return
+	}
+
+	frequencies := make([]keyFrequency, 0, sample)
+	err = forEachKeyBatch(c, client, scopePattern(id, c.DefaultQuery("pattern", "*")), func(keys []string) error {
+		if remaining := sample - len(frequencies); len(keys) > remaining {
+			keys = keys[:remaining]
+		}
+		cmds := make([]*redis.Cmd, len(keys))
+		// Keys deleted since the scan fail individually with redis.Nil, so
+		// errors are checked per command
+		client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Do(c, "OBJECT", "FREQ", key)
+			}
+			return nil
+		})
+		for i, cmd := range cmds {
+			freq, err := cmd.Int64()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read OBJECT FREQ: %w", err)
+			}
+			frequencies = append(frequencies, keyFrequency{Key: keys[i], Freq: freq})
+		}
+		if len(frequencies) >= sample {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	sort.SliceStable(frequencies, func(i, j int) bool { return frequencies[i].Freq < frequencies[j].Freq })
+	sampled := len(frequencies)
+	if len(frequencies) > count {
+		frequencies = frequencies[:count]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy":  policy["maxmemory-policy"],
+		"sampled": sampled,
+		"keys":    frequencies,
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// concurrencyLimits holds a semaphore per connection with a configured
+// MaxConcurrency. Connections without a limit have no entry.
+var (
+	concurrencyLimits   = make(map[string]chan struct{})
+	concurrencyLimitsMu sync.Mutex
+)
+
+// setConcurrencyLimit configures how many requests may use connection id at
+// once; 0 removes the limit. Requests already running keep their slot in the
+// previous semaphore.
+func setConcurrencyLimit(id string, limit int) {
+	concurrencyLimitsMu.Lock()
+	defer concurrencyLimitsMu.Unlock()
+
+	if limit <= 0 {
+		delete(concurrencyLimits, id)
+		return
+	}
+	concurrencyLimits[id] = make(chan struct{}, limit)
+}
+
+// forgetConcurrencyLimit drops the semaphore of a deleted connection.
+func forgetConcurrencyLimit(id string) {
+	setConcurrencyLimit(id, 0)
+}
+
+// limitConcurrency is the middleware enforcing the per-connection limit for
+// every route with an :id parameter. Requests over the limit are rejected
+// with 429 instead of queueing, so the tool never piles work onto a busy
+// server.
+func limitConcurrency(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.Next()
+		return
+	}
+
+	concurrencyLimitsMu.Lock()
+	sem, limited := concurrencyLimits[id]
+	concurrencyLimitsMu.Unlock()
+	if !limited {
+		c.Next()
+		return
+	}
+
+	select {
+	case sem <- struct{}{}:
+		defer func() { <-sem }()
+		c.Next()
+	default:
+		respondError(c, http.StatusTooManyRequests, errTooManyRequests, fmt.Sprintf("Connection '%s' is already running %d operations, try again shortly", id, cap(sem)))
+		c.Abort()
+	}
+}
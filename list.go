@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// findListPosition runs LPOS. ?value is matched byte for byte against the
+// stored element; binary elements, shown as base64 by getKey, can be matched
+// with ?encoding=base64. Without ?count the first match (or the match chosen
+// by ?rank) is returned as index, with ?count the matches are returned as
+// indices, count=0 meaning all of them.
+func findListPosition(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	value, hasValue := c.GetQuery("value")
+	if !hasValue {
+		respondError(c, http.StatusBadRequest, errBadRequest, "value is required")
+		return
+	}
+	if c.Query("encoding") == "base64" {
+		raw, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, "value is not valid base64")
+			return
+		}
+		value = string(raw)
+	}
+
+	var args redis.LPosArgs
+	if s := c.Query("rank"); s != "" {
+		rank, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || rank == 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "rank must be a non-zero integer")
+			return
+		}
+		args.Rank = rank
+	}
+	if s := c.Query("maxlen"); s != "" {
+		maxLen, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || maxLen < 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid maxlen")
+			return
+		}
+		args.MaxLen = maxLen
+	}
+
+	countStr, hasCount := c.GetQuery("count")
+	if !hasCount {
+		index, err := client.LPos(c, key, value, args).Result()
+		if errors.Is(err, redis.Nil) {
+			c.JSON(http.StatusOK, gin.H{"index": nil})
+			return
+		}
+		if err != nil {
+			respondListError(c, client, key, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"index": index})
+		return
+	}
+
+	count, err := strconv.ParseInt(countStr, 10, 64)
+	if err != nil || count < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid count")
+		return
+	}
+	indices, err := client.LPosCount(c, key, value, count, args).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		respondListError(c, client, key, err)
+		return
+	}
+	if indices == nil {
+		indices = []int64{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"indices": indices})
+}
+
+func respondListError(c *gin.Context, client *redis.Client, key string, err error) {
+	if isWrongType(err) {
+		respondWrongType(c, client, key, "list")
+		return
+	}
+	respondRedisError(c, err, fmt.Sprintf("Failed to search list: %v", err))
+}
@@ -1,30 +1,302 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"math"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 )
 
 type RedisConnection struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	Port     string `json:"port"`
-	Password string `json:"password"`
-	DB       int    `json:"db"`
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Host           string `json:"host"`
+	Port           string `json:"port"`
+	Password       string `json:"password,omitempty"` // accepted on create, never returned
+	HasPassword    bool   `json:"hasPassword"`
+	ClearPassword  bool   `json:"clearPassword,omitempty"` // on create, drop the stored password of an existing ID
+	DB             int    `json:"db"`
+	CommandTimeout int    `json:"commandTimeout"` // milliseconds
+	ClientName     string `json:"clientName"`
+	Protocol       int    `json:"protocol"`       // 2 or 3, 0 uses the go-redis default
+	MaxConcurrency int    `json:"maxConcurrency"` // concurrent requests, 0 means unlimited
+	KeyPrefix      string `json:"keyPrefix"`      // only keys under this prefix are visible
+	NoTouch        bool   `json:"noTouch"`        // reads do not change the LRU/LFU of keys, Redis 7.2+
+	NoEvict        bool   `json:"noEvict"`        // exempt from client eviction, Redis 7.0+
+	DefaultTTL     int    `json:"defaultTTL"`     // seconds given to keys written without a TTL, 0 means none
+
+	// Managed with the tag endpoints, ignored on create
+	Tags []string `json:"tags"`
+
+	// Detected from INFO when the connection is added, ignored on create
+	Mode        string `json:"mode,omitempty"` // "standalone", "cluster" or "sentinel"
+	ModeWarning string `json:"modeWarning,omitempty"`
+
+	// Health, only set in listings
+	Status      string  `json:"status,omitempty"` // "connected", "error" or "skipped"
+	LatencyMs   float64 `json:"latencyMs,omitempty"`
+	StatusError string  `json:"statusError,omitempty"`
 }
 
-var connections = make(map[string]*redis.Client)
+// toConnection converts an API connection into its stored form.
+func (conn RedisConnection) toConnection() Connection {
+	return Connection{
+		ID:             conn.ID,
+		Name:           conn.Name,
+		Host:           conn.Host,
+		Port:           conn.Port,
+		Password:       conn.Password,
+		DB:             conn.DB,
+		CommandTimeout: conn.CommandTimeout,
+		ClientName:     conn.ClientName,
+		Protocol:       conn.Protocol,
+		MaxConcurrency: conn.MaxConcurrency,
+		KeyPrefix:      conn.KeyPrefix,
+		NoTouch:        conn.NoTouch,
+		NoEvict:        conn.NoEvict,
+		DefaultTTL:     conn.DefaultTTL,
+	}
+}
+
+// newRedisConnection converts a stored connection into its API form. The
+// password is left out; revealConnectionPassword is the only way to read it
+// back.
+func newRedisConnection(conn Connection) RedisConnection {
+	return RedisConnection{
+		ID:             conn.ID,
+		Name:           conn.Name,
+		Host:           conn.Host,
+		Port:           conn.Port,
+		HasPassword:    conn.Password != "",
+		DB:             conn.DB,
+		CommandTimeout: conn.CommandTimeout,
+		ClientName:     conn.ClientName,
+		Protocol:       conn.Protocol,
+		MaxConcurrency: conn.MaxConcurrency,
+		KeyPrefix:      conn.KeyPrefix,
+		NoTouch:        conn.NoTouch,
+		NoEvict:        conn.NoEvict,
+		DefaultTTL:     conn.DefaultTTL,
+		Mode:           conn.Mode,
+		Tags:           []string{},
+	}
+}
+
+// connections holds the client of every saved connection by ID. Handlers run
+// concurrently, so it is only accessed through the functions below.
+var (
+	connections   = make(map[string]*redis.Client)
+	connectionsMu sync.RWMutex
+)
+
+func getConnection(id string) (*redis.Client, bool) {
+	connectionsMu.RLock()
+	defer connectionsMu.RUnlock()
+	client, exists := connections[id]
+	return client, exists
+}
+
+// setConnection stores the client of a connection and returns the client it
+// replaced, if any, which the caller must release.
+func setConnection(id string, client *redis.Client) (*redis.Client, bool) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	previous, replaced := connections[id]
+	connections[id] = client
+	return previous, replaced
+}
+
+// removeConnection deletes a connection and returns its client, so only one
+// of two concurrent deletes gets to close it.
+func removeConnection(id string) (*redis.Client, bool) {
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+	client, exists := connections[id]
+	delete(connections, id)
+	return client, exists
+}
+
+func connectionIDs() []string {
+	connectionsMu.RLock()
+	defer connectionsMu.RUnlock()
+	ids := make([]string, 0, len(connections))
+	for id := range connections {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// dbClients holds one client per (connection, database) pair, keyed by
+// "<id>/<db>". Sharing a pool and issuing SELECT per request only switches
+// whichever pooled connection SELECT happened to run on, so every database
+// gets its own pool instead.
+var (
+	dbClients   = make(map[string]*redis.Client)
+	dbClientsMu sync.Mutex
+)
+
+// maxDBClients is how many databases of one connection keep a cached pool.
+// Further databases get a client closed at the end of the request, so a
+// caller walking database numbers cannot pile up pools.
+var maxDBClients = envInt("WEBREDIS_MAX_DB_CLIENTS", 16)
+
+// dbClientIdleTimeout is how long a pooled connection of a database client
+// stays open unused, so rarely browsed databases do not hold connections.
+const dbClientIdleTimeout = 5 * time.Minute
+
+// clientForDB returns the client for database db of connection id. It writes
+// the error response itself and returns false if the connection is unknown or
+// db is not a valid database number.
+func clientForDB(c *gin.Context, id, db string) (*redis.Client, bool) {
+	base, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return nil, false
+	}
+	// Every /key route names its key in the path
+	if key := c.Param("key"); key != "" && !requireKeysInScope(c, id, key) {
+		return nil, false
+	}
+
+	index, err := strconv.Atoi(db)
+	if err != nil || index < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid database number")
+		return nil, false
+	}
+
+	dbClientsMu.Lock()
+	defer dbClientsMu.Unlock()
+
+	// Keyed by the parsed number, so "1" and "01" share a pool
+	cacheKey := fmt.Sprintf("%s/%d", id, index)
+	client, ok := dbClients[cacheKey]
+	if !ok {
+		options := *base.Options()
+		options.DB = index
+		options.MinIdleConns = 0
+		options.ConnMaxIdleTime = dbClientIdleTimeout
+		client = redis.NewClient(&options)
+		if countDBClients(id) >= maxDBClients {
+			scopeToRequest(c, client)
+			return withRequestTimeout(c, client), true
+		}
+		dbClients[cacheKey] = client
+	}
+	return withRequestTimeout(c, client), true
+}
+
+// countDBClients returns how many database clients of connection id are
+// cached. dbClientsMu must be held.
+func countDBClients(id string) int {
+	prefix := id + "/"
+	count := 0
+	for cacheKey := range dbClients {
+		if strings.HasPrefix(cacheKey, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+// closeDBClients closes the per-database clients of connection id.
+func closeDBClients(id string) {
+	dbClientsMu.Lock()
+	defer dbClientsMu.Unlock()
+
+	prefix := id + "/"
+	for cacheKey, client := range dbClients {
+		if strings.HasPrefix(cacheKey, prefix) {
+			client.Close()
+			delete(dbClients, cacheKey)
+		}
+	}
+}
+
+// releaseConnection closes the clients of connection id and drops what is
+// cached about the server behind it, once the connection was deleted,
+// renamed or saved again. Its settings, such as the key prefix, are left to
+// the caller.
+func releaseConnection(id string, client *redis.Client) {
+	client.Close()
+	closeDBClients(id)
+	closeTrackingConn(id)
+	forgetConnectionStatus(id)
+	forgetChangelogs(id)
+	forgetServerInfo(id)
+	forgetCommandCatalog(id)
+	forgetReplyCache(id)
+	forgetKeyCounts(id)
+}
+
+// defaultCommandTimeout bounds a single Redis command when the connection does
+// not configure its own timeout.
+const defaultCommandTimeout = 3 * time.Second
+
+// newRedisClient builds a client for a saved connection. Every command gets
+// its own read/write deadline from the connection's command timeout, so a slow
+// command fails and releases its pool connection instead of waiting on the
+// HTTP client. Context deadlines, when set, take precedence.
+func newRedisClient(conn Connection) *redis.Client {
+	timeout := defaultCommandTimeout
+	if conn.CommandTimeout > 0 {
+		timeout = time.Duration(conn.CommandTimeout) * time.Millisecond
+	}
+
+	options := &redis.Options{
+		Addr:                  fmt.Sprintf("%s:%s", conn.Host, conn.Port),
+		DB:                    conn.DB,
+		Protocol:              conn.Protocol,
+		ReadTimeout:           timeout,
+		WriteTimeout:          timeout,
+		ContextTimeoutEnabled: true,
+	}
+
+	// Only set password if it's not empty
+	if conn.Password != "" {
+		options.Password = conn.Password
+	}
+
+	// Name every connection so webredis shows up clearly in CLIENT LIST
+	clientName := conn.ClientName
+	if clientName == "" {
+		clientName = "webredis:" + strings.ReplaceAll(conn.ID, " ", "_")
+	}
+	options.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+		if err := cn.ClientSetName(ctx, clientName).Err(); err != nil {
+			// Not fatal, e.g. the ACL user may not be allowed to run CLIENT
+			log.Printf("Warning: Failed to set client name %q: %v", clientName, err)
+		}
+		// Keep browsing and scans from skewing eviction. Older servers do
+		// not know these flags, which is not worth a warning per connection
+		if conn.NoTouch {
+			if err := runOnConn(ctx, cn, "CLIENT", "NO-TOUCH", "ON").Err(); err != nil && !isUnsupportedCommand(err) {
+				log.Printf("Warning: Failed to set CLIENT NO-TOUCH: %v", err)
+			}
+		}
+		if conn.NoEvict {
+			if err := runOnConn(ctx, cn, "CLIENT", "NO-EVICT", "ON").Err(); err != nil && !isUnsupportedCommand(err) {
+				log.Printf("Warning: Failed to set CLIENT NO-EVICT: %v", err)
+			}
+		}
+		return nil
+	}
+
+	return redis.NewClient(options)
+}
 
 func main() {
 	// Initialize database
@@ -36,27 +308,21 @@ func main() {
 	savedConnections, err := loadConnections()
 	if err != nil {
 		log.Printf("Warning: Failed to load saved connections: %v", err)
-	} else {
-		for _, conn := range savedConnections {
-			options := &redis.Options{
-				Addr: fmt.Sprintf("%s:%s", conn.Host, conn.Port),
-				DB:   conn.DB,
-			}
-			if conn.Password != "" {
-				options.Password = conn.Password
-			}
-			client := redis.NewClient(options)
-			connections[conn.ID] = client
-		}
+	} else if err := connectSavedConnections(savedConnections); err != nil {
+		log.Fatalf("Failed to connect saved connections: %v", err)
 	}
 
 	r := gin.Default()
+	// Let the gin context report the request's cancellation and deadline, so
+	// Redis calls made with it stop when the browser goes away.
+	r.ContextWithFallback = true
 
 	// CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, If-None-Match, Idempotency-Key, X-Admin-Token, X-Redis-Timeout")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag, Content-Disposition, Idempotent-Replayed, X-Export-Keys, X-Export-Skipped, X-Redis-Timeout")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
 			return
@@ -66,23 +332,132 @@ func main() {
 
 	// API routes
 	api := r.Group("/api")
+	api.Use(limitConcurrency, requestTimeout, idempotency)
 	{
 		api.POST("/connections", createConnection)
 		api.GET("/connections", listConnections)
+		api.GET("/self/stats", getSelfStats)
 		api.DELETE("/connections/:id", deleteConnection)
+		api.POST("/connections/:id/rename-id", renameConnectionID)
+		api.GET("/connections/:id/password", revealConnectionPassword)
+		api.POST("/connections/:id/tags", addTags)
+		api.POST("/connections/:id/warmup", warmupConnection)
+		api.DELETE("/connections/:id/tags/:tag", removeTag)
 		api.GET("/databases/:id", listDatabases)
+		api.GET("/server/:id", getServerInfo)
+		api.POST("/environments", createEnvironment)
+		api.GET("/environments", listEnvironments)
+		api.DELETE("/environments/:envId", deleteEnvironment)
+		api.GET("/keys/env/:envId", listEnvironmentKeys)
 		api.GET("/keys/:id/:db", listKeys)
+		api.GET("/keys/:id/:db/count", getKeyCount)
+		api.POST("/keys/:id/:db/expire-by-pattern", expireByPattern)
+		api.POST("/keys/:id/:db/persist-by-pattern", persistByPattern)
+		api.POST("/keys/:id/:db/mget", multiGetKeys)
+		api.POST("/keys/:id/:db/touch", touchKeys)
+		api.POST("/transform/:id/:db", transformKeys)
+		api.GET("/keys/:id/:db/lfu-bottom", getLFUBottom)
+		api.GET("/keys/:id/:db/encoding-report", getEncodingReport)
+		api.GET("/keys/:id/:db/type-breakdown", getTypeBreakdown)
+		api.GET("/keys/:id/:db/access-stats", getAccessStats)
+		api.GET("/keys/:id/:db/anomalies", findAnomalies)
+		api.GET("/keys/:id/:db/memory-estimate", estimateMemory)
+		api.GET("/export/:id/:db", exportKeys)
+		api.GET("/stream-keys/:id/:db", streamKeys)
+		api.POST("/import/:id/:db", importKeys)
+		api.POST("/diff", diffConnections)
+		api.POST("/compare", compareKeys)
 		api.GET("/key/:id/:db/:key", getKey)
 		api.POST("/key/:id/:db/:key", setKey)
 		api.DELETE("/key/:id/:db/:key", deleteKey)
+		api.GET("/key/:id/:db/:key/meta", getKeyMeta)
+		api.PUT("/key/:id/:db/:key/ttl", setKeyTTL)
+		api.GET("/key/:id/:db/:key/getex", getKeyEx)
+		api.GET("/key/:id/:db/:key/raw", getKeyRaw)
+		api.POST("/key/:id/:db/:key/upload", uploadKey)
+		api.POST("/key/:id/:db/:key/cas", compareAndSetKey)
+		api.POST("/key/:id/:db/:key/append", appendKey)
+		api.POST("/key/:id/:db/:key/clear", clearKey)
+		api.PUT("/key/:id/:db/:key/range", setKeyRange)
+		api.GET("/key/:id/:db/:key/hash", listHashFields)
+		api.GET("/key/:id/:db/:key/sample", sampleKey)
+		api.POST("/key/:id/:db/:key/changelog", watchKeyChanges)
+		api.GET("/key/:id/:db/:key/changelog", getKeyChangelog)
+		api.DELETE("/key/:id/:db/:key/changelog", unwatchKeyChanges)
+		api.PUT("/key/:id/:db/:key/hash/:field", setHashField)
+		api.DELETE("/key/:id/:db/:key/hash/:field", deleteHashField)
+		api.GET("/key/:id/:db/:key/hash/ttls", getHashFieldTTLs)
+		api.POST("/key/:id/:db/:key/hash/:field/ttl", setHashFieldTTL)
+		api.DELETE("/key/:id/:db/:key/hash/:field/ttl", persistHashField)
+		api.GET("/key/:id/:db/:key/probabilistic", getProbabilisticInfo)
+		api.GET("/key/:id/:db/:key/bloom/exists", checkFilterItems)
+		api.POST("/key/:id/:db/:key/bloom/add", addFilterItems)
+		api.GET("/key/:id/:db/:key/cms/query", queryCountMinSketch)
+		api.POST("/key/:id/:db/:key/sort", sortKey)
+		api.GET("/key/:id/:db/:key/lpos", findListPosition)
+		api.GET("/key/:id/:db/:key/bitcount", getBitCount)
+		api.GET("/key/:id/:db/:key/bitpos", getBitPos)
+		api.POST("/key/:id/:db/:key/bitfield", runBitfield)
+		api.POST("/key/:id/:db/:key/set/toggle", toggleSetMember)
+		api.GET("/key/:id/:db/:key/zrank-range", getZSetRankRange)
+		api.POST("/key/:id/:db/:key/zset/add", addZSetMembers)
+		api.POST("/sets/:id/:db/move", moveSetMember)
+		api.POST("/sets/:id/:db/intercard", countSetIntersection)
+		api.POST("/lcs/:id/:db", longestCommonSubsequence)
+		api.POST("/stream/:id/:db/:key/trim", trimStream)
+		api.GET("/stream/:id/:db/:key/range-by-time", getStreamRangeByTime)
+		api.GET("/stream/:id/:db/:key/groups", listStreamGroups)
+		api.GET("/stream/:id/:db/:key/groups/:group/consumers", listStreamConsumers)
+		api.GET("/stream/:id/:db/:key/groups/:group/pending", getStreamPending)
+		api.POST("/stream/:id/:db/:key/groups/:group/ack", ackStreamEntries)
+		api.POST("/stream/:id/:db/:key/groups/:group/claim", claimStreamEntries)
 		api.POST("/execute/:id/:db", executeCommand)
+		api.POST("/execute-stream/:id/:db", executeCommandStream)
+		api.GET("/command-docs/:id", getCommandDocs)
+		api.GET("/commands/:id/catalog", getCommandCatalog)
+		api.GET("/tracking/:id", getTrackingInfo)
+		api.POST("/tracking/:id", setTracking)
+		api.GET("/pubsub/:id", getPubSubInfo)
+		api.GET("/time/:id", getServerTime)
+		api.GET("/cluster/:id/slots", getClusterSlots)
+		api.GET("/cluster/:id/nodes", getClusterNodes)
+		api.GET("/cluster/:id/keyslot", getClusterKeySlot)
+		api.GET("/ping-bench/:id", benchmarkPing)
+		api.GET("/commandstats/:id", getCommandStats)
+		api.POST("/commandstats/:id/reset", resetCommandStats)
+		api.GET("/acl/:id/whoami", getACLWhoami)
+		api.GET("/acl/:id/getuser", getACLUser)
+		api.GET("/config/:id/output-buffer-limits", getOutputBufferLimits)
+		api.PUT("/config/:id/output-buffer-limits", setOutputBufferLimits)
+		api.GET("/monitor/:id", monitorCommands)
+		api.POST("/debug/:id", runDebugCommand)
+		api.GET("/keyspace/:id/:db", keyspaceFeed)
+		api.POST("/save/:id", saveSnapshot)
+		api.POST("/swapdb/:id", swapDatabases)
+		api.GET("/memory/:id/stats", getMemoryStats)
+		api.GET("/memory/:id/doctor", getMemoryDoctor)
+		api.GET("/lastsave/:id", getLastSave)
+		api.POST("/waitaof/:id", waitAOF)
+		api.GET("/persistence/:id", getPersistenceState)
+		api.POST("/persistence/:id/rewrite-aof", rewriteAOF)
 	}
 
 	// Serve static files - must be after API routes
-	r.NoRoute(func(c *gin.Context) {
-		c.File("./frontend/dist/index.html")
-	})
-	r.Static("/assets", "./frontend/dist/assets")
+	if os.Getenv("WEBREDIS_DISABLE_STATIC") == "true" {
+		// API-only mode, the frontend is served by something else
+		r.NoRoute(func(c *gin.Context) {
+			respondError(c, http.StatusNotFound, errNotFound, "Not found")
+		})
+	} else {
+		staticDir := os.Getenv("WEBREDIS_STATIC_DIR")
+		if staticDir == "" {
+			staticDir = "./frontend/dist"
+		}
+		r.NoRoute(func(c *gin.Context) {
+			c.File(filepath.Join(staticDir, "index.html"))
+		})
+		r.Static("/assets", filepath.Join(staticDir, "assets"))
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -94,26 +469,28 @@ func main() {
 func createConnection(c *gin.Context) {
 	var conn RedisConnection
 	if err := c.ShouldBindJSON(&conn); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
 		return
 	}
 
-	options := &redis.Options{
-		Addr: fmt.Sprintf("%s:%s", conn.Host, conn.Port),
-		DB:   conn.DB,
+	if conn.CommandTimeout < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "commandTimeout must not be negative")
+		return
 	}
-
-	// Only set password if it's not empty
-	if conn.Password != "" {
-		options.Password = conn.Password
+	if conn.MaxConcurrency < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "maxConcurrency must not be negative")
+		return
 	}
-
-	client := redis.NewClient(options)
-
-	// Test connection
-	if err := client.Ping(c).Err(); err != nil {
-		log.Printf("Connection failed: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to connect to Redis"})
+	if conn.DefaultTTL < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "defaultTTL must not be negative")
+		return
+	}
+	if conn.Protocol != 0 && conn.Protocol != 2 && conn.Protocol != 3 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "protocol must be 2 or 3")
+		return
+	}
+	if strings.ContainsAny(conn.ClientName, " \t\r\n") {
+		respondError(c, http.StatusBadRequest, errBadRequest, "clientName must not contain spaces")
 		return
 	}
 
@@ -127,50 +504,127 @@ func createConnection(c *gin.Context) {
 		conn.Name = conn.ID
 	}
 
-	connections[conn.ID] = client
+	// Listings never return the password, so a form saving an existing ID
+	// again sends none; keep the stored one unless told to clear it
+	if conn.Password == "" && !conn.ClearPassword {
+		if stored, err := getConnectionFromDB(conn.ID); err == nil {
+			conn.Password = stored.Password
+		}
+	}
 
-	// Save connection to database
-	dbConn := Connection{
-		ID:       conn.ID,
-		Name:     conn.Name,
-		Host:     conn.Host,
-		Port:     conn.Port,
-		Password: conn.Password,
-		DB:       conn.DB,
+	dbConn := conn.toConnection()
+	client := newRedisClient(dbConn)
+
+	// Test connection
+	if err := client.Ping(c).Err(); err != nil {
+		log.Printf("Connection failed: %v", err)
+		client.Close()
+		respondError(c, http.StatusBadRequest, errConnectionFailed, "Failed to connect to Redis")
+		return
+	}
+	mode, modeWarning := detectServerMode(c, client)
+	dbConn.Mode = mode
+
+	// The settings go first, so requests never see the new client with the
+	// old key prefix
+	setConcurrencyLimit(conn.ID, conn.MaxConcurrency)
+	setKeyPrefix(conn.ID, conn.KeyPrefix)
+	setDefaultTTL(conn.ID, conn.DefaultTTL)
+	if previous, replaced := setConnection(conn.ID, client); replaced {
+		// Saving an existing ID again may point it at another server
+		releaseConnection(conn.ID, previous)
 	}
+	forgetSkippedConnection(conn.ID)
+
+	// Save connection to database
 	if err := saveConnection(dbConn); err != nil {
 		log.Printf("Warning: Failed to save connection to database: %v", err)
 	}
 
-	c.JSON(http.StatusOK, conn)
+	// Saving an existing ID again keeps its tags
+	saved := newRedisConnection(dbConn)
+	saved.Tags = tagsOf(conn.ID)
+	saved.ModeWarning = modeWarning
+	c.JSON(http.StatusOK, saved)
 }
 
+// listConnections returns every saved connection with its status. ?tag=
+// (repeatable) keeps the connections carrying all the given tags.
 func listConnections(c *gin.Context) {
-	conns := make([]RedisConnection, 0, len(connections))
-	for id := range connections {
+	var tagged map[string]bool
+	if filter := c.QueryArray("tag"); len(filter) > 0 {
+		var err error
+		tagged, err = connectionIDsWithTags(filter)
+		if err != nil {
+			respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to filter by tag: %v", err))
+			return
+		}
+	}
+	tags, err := loadConnectionTags()
+	if err != nil {
+		log.Printf("Warning: Failed to load connection tags: %v", err)
+	}
+	withTags := func(conn RedisConnection) RedisConnection {
+		if tags[conn.ID] != nil {
+			conn.Tags = tags[conn.ID]
+		}
+		return conn
+	}
+
+	saved := connectionIDs()
+	conns := make([]RedisConnection, 0, len(saved))
+	for _, id := range saved {
+		if tagged != nil && !tagged[id] {
+			continue
+		}
 		// Get connection details from database
 		conn, err := getConnectionFromDB(id)
 		if err != nil {
 			log.Printf("Warning: Failed to get connection details from database: %v", err)
 			continue
 		}
-		conns = append(conns, RedisConnection{
-			ID:       conn.ID,
-			Name:     conn.Name,
-			Host:     conn.Host,
-			Port:     conn.Port,
-			Password: conn.Password,
-			DB:       conn.DB,
-		})
+		conns = append(conns, withTags(newRedisConnection(conn)))
+	}
+
+	// Ping every server so the UI can tell which ones are reachable
+	ids := make([]string, len(conns))
+	for i, conn := range conns {
+		ids[i] = conn.ID
+	}
+	statuses := connectionStatuses(c, ids)
+	for i := range conns {
+		status := statuses[conns[i].ID]
+		conns[i].Status = status.Status
+		conns[i].LatencyMs = status.LatencyMs
+		conns[i].StatusError = status.Error
 	}
+
+	// Connections left out at startup are listed so they can be fixed or
+	// deleted
+	for id, status := range skippedConnectionStatuses() {
+		if tagged != nil && !tagged[id] {
+			continue
+		}
+		conn, err := getConnectionFromDB(id)
+		if err != nil {
+			continue
+		}
+		skipped := withTags(newRedisConnection(conn))
+		skipped.Status = status.Status
+		skipped.StatusError = status.Error
+		conns = append(conns, skipped)
+	}
+
 	c.JSON(http.StatusOK, conns)
 }
 
 func deleteConnection(c *gin.Context) {
 	id := c.Param("id")
-	if client, exists := connections[id]; exists {
-		client.Close()
-		delete(connections, id)
+	if client, exists := removeConnection(id); exists {
+		releaseConnection(id, client)
+		forgetConcurrencyLimit(id)
+		forgetKeyPrefix(id)
+		forgetDefaultTTL(id)
 		// Delete from database
 		if err := deleteConnectionFromDB(id); err != nil {
 			log.Printf("Warning: Failed to delete connection from database: %v", err)
@@ -178,14 +632,105 @@ func deleteConnection(c *gin.Context) {
 		c.Status(http.StatusOK)
 		return
 	}
-	c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+	if forgetSkippedConnection(id) {
+		if err := deleteConnectionFromDB(id); err != nil {
+			log.Printf("Warning: Failed to delete connection from database: %v", err)
+		}
+		c.Status(http.StatusOK)
+		return
+	}
+	respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+}
+
+// revealConnectionPassword returns the stored password of a connection, for
+// when a user needs to copy the credential. Listings never include it.
+func revealConnectionPassword(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	conn, err := getConnectionFromDB(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to read connection: %v", err))
+		return
+	}
+
+	log.Printf("Password of connection %s revealed to %s", id, c.ClientIP())
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"password": conn.Password})
+}
+
+// renameConnectionID changes the ID a connection is stored and addressed by.
+// The client is rebuilt so its default CLIENT SETNAME follows the new ID.
+func renameConnectionID(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data struct {
+		ID string `json:"id"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.ID == "" || strings.Contains(data.ID, "/") {
+		respondError(c, http.StatusBadRequest, errBadRequest, "id is required and must not contain '/'")
+		return
+	}
+	if data.ID == id {
+		respondError(c, http.StatusBadRequest, errBadRequest, "id is unchanged")
+		return
+	}
+	if _, taken := getConnection(data.ID); taken {
+		respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("Connection '%s' already exists", data.ID))
+		return
+	}
+
+	if err := renameConnectionInDB(id, data.ID); err != nil {
+		if errors.Is(err, errConnectionExists) {
+			respondError(c, http.StatusConflict, errConflict, fmt.Sprintf("Connection '%s' already exists", data.ID))
+			return
+		}
+		log.Printf("Failed to rename connection %s: %v", id, err)
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to rename connection: %v", err))
+		return
+	}
+
+	conn, err := getConnectionFromDB(data.ID)
+	if err != nil {
+		log.Printf("Failed to reload connection %s: %v", data.ID, err)
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to reload connection: %v", err))
+		return
+	}
+
+	removeConnection(id)
+	releaseConnection(id, client)
+	forgetConcurrencyLimit(id)
+	forgetKeyPrefix(id)
+	forgetDefaultTTL(id)
+	setConnection(data.ID, newRedisClient(conn))
+	setConcurrencyLimit(data.ID, conn.MaxConcurrency)
+	setKeyPrefix(data.ID, conn.KeyPrefix)
+	setDefaultTTL(data.ID, conn.DefaultTTL)
+
+	c.JSON(http.StatusOK, newRedisConnection(conn))
 }
 
 func listDatabases(c *gin.Context) {
 	id := c.Param("id")
-	_, exists := connections[id]
+	_, exists := getConnection(id)
 	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
 		return
 	}
 
@@ -208,41 +753,61 @@ func listKeys(c *gin.Context) {
 	cursor, err := strconv.ParseUint(cursorStr, 10, 64)
 	if err != nil {
 		log.Printf("Invalid cursor value: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor value"})
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid cursor value")
 		return
 	}
 
 	batchSize, err := strconv.ParseInt(batchSizeStr, 10, 64)
 	if err != nil {
 		log.Printf("Invalid batch size: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch size"})
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid batch size")
 		return
 	}
 
-	client, exists := connections[id]
-	if !exists {
-		log.Printf("Connection not found: %s", id)
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+	ttlFilter := c.DefaultQuery("ttlFilter", "any")
+	switch ttlFilter {
+	case "any", "with", "without":
+	default:
+		respondError(c, http.StatusBadRequest, errBadRequest, "ttlFilter must be any, with or without")
 		return
 	}
 
-	// Select database
-	if err := client.Do(c, "SELECT", db).Err(); err != nil {
-		log.Printf("Failed to select database: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to select database: %v", err)})
+	client, ok := clientForDB(c, id, db)
+	if !ok {
 		return
 	}
 
 	// Use SCAN with a larger count
-	keys, nextCursor, err := client.Scan(c, cursor, "*", batchSize).Result()
+	keys, nextCursor, err := client.Scan(c, cursor, scopePattern(id, "*"), batchSize).Result()
 	if err != nil {
 		log.Printf("Failed to scan keys: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to scan keys: %v", err)})
+		respondRedisError(c, err, fmt.Sprintf("Failed to scan keys: %v", err))
 		return
 	}
 
 	log.Printf("Found %d keys in database %s", len(keys), db)
 
+	if ttlFilter != "any" {
+		keys, err = filterKeysByTTL(c, client, keys, ttlFilter == "with")
+		if err != nil {
+			respondRedisError(c, err, fmt.Sprintf("Failed to read TTLs: %v", err))
+			return
+		}
+	}
+
+	// Pollers that already have this page get a 304 before any per-key work
+	dbSize, err := client.DBSize(c).Result()
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read database size: %v", err))
+		return
+	}
+	etag := keyListingETag(cursor, nextCursor, dbSize, keys)
+	c.Header("ETag", etag)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	// Get TTL and type for each key in parallel using goroutines
 	keyInfo := make([]map[string]interface{}, len(keys))
 	type result struct {
@@ -269,24 +834,16 @@ func listKeys(c *gin.Context) {
 
 		go func(start, end int) {
 			for j := start; j < end; j++ {
-				key := keys[j]
-				ttl, err := client.TTL(c, key).Result()
-				if err != nil {
-					ttl = -2 // Error value
+				// Stop early if the client gave up on the request
+				if c.Err() != nil {
+					return
 				}
-
-				keyType, err := client.Type(c, key).Result()
-				if err != nil {
-					keyType = "unknown"
-				}
-
+				key := keys[j]
+				pttl, pttlErr := client.PTTL(c, key).Result()
+				keyType, typeErr := client.Type(c, key).Result()
 				resultChan <- result{
 					index: j,
-					info: map[string]interface{}{
-						"key":  key,
-						"ttl":  ttl.Seconds(),
-						"type": keyType,
-					},
+					info:  keyListingEntry(key, keyType, typeErr, pttl, pttlErr),
 				}
 			}
 		}(i, end)
@@ -299,11 +856,16 @@ func listKeys(c *gin.Context) {
 			keyInfo[res.index] = res.info
 		case err := <-errorChan:
 			log.Printf("Error getting key info: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get key info: %v", err)})
+			respondRedisError(c, err, fmt.Sprintf("Failed to get key info: %v", err))
+			return
+		case <-c.Done():
+			log.Printf("Listing keys cancelled: %v", c.Err())
 			return
 		}
 	}
 
+	keyInfo = dropMissingKeys(keyInfo)
+
 	log.Printf("Successfully processed %d keys", len(keyInfo))
 
 	// Return the response in the expected format
@@ -314,374 +876,682 @@ func listKeys(c *gin.Context) {
 	})
 }
 
+// keyListingEntry builds the listing entry of a key from its TYPE and PTTL
+// replies. It returns nil when the key expired or was deleted after SCAN
+// returned it, which TYPE reports as "none" and PTTL as -2.
+func keyListingEntry(key, keyType string, typeErr error, pttl time.Duration, pttlErr error) map[string]interface{} {
+	if (typeErr == nil && keyType == "none") || (pttlErr == nil && pttl == -2) {
+		return nil
+	}
+	if pttlErr != nil {
+		pttl = -2 // Error value
+	}
+	if typeErr != nil {
+		keyType = "unknown"
+	}
+	info := map[string]interface{}{
+		"key":  key,
+		"type": keyType,
+	}
+	addTTLFields(info, pttl)
+	return info
+}
+
+// dropMissingKeys leaves out the nil entries of keys that disappeared
+// mid-scan, keeping the order of the others.
+func dropMissingKeys(keyInfo []map[string]interface{}) []map[string]interface{} {
+	existing := keyInfo[:0]
+	for _, info := range keyInfo {
+		if info != nil {
+			existing = append(existing, info)
+		}
+	}
+	return existing
+}
+
+// filterKeysByTTL keeps the keys that have an expiry (withTTL) or that have
+// none, reading their PTTL in one pipeline. Keys gone since the scan are
+// dropped either way.
+func filterKeysByTTL(ctx context.Context, client *redis.Client, keys []string, withTTL bool) ([]string, error) {
+	cmds := make([]*redis.DurationCmd, len(keys))
+	if _, err := client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	filtered := make([]string, 0, len(keys))
+	for i, key := range keys {
+		pttl := cmds[i].Val()
+		if pttl == -2 {
+			continue
+		}
+		if (pttl >= 0) == withTTL {
+			filtered = append(filtered, key)
+		}
+	}
+	return filtered, nil
+}
+
 func getKey(c *gin.Context) {
 	id := c.Param("id")
 	db := c.Param("db")
 	key := c.Param("key")
-	client, exists := connections[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+	client, ok := clientForDB(c, id, db)
+	if !ok {
 		return
 	}
 
-	// Select database
-	if err := client.Do(c, "SELECT", db).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to select database: %v", err)})
+	// ?encoding=text or binary overrides how a string value is decoded
+	encoding := c.Query("encoding")
+	if !validEncoding(encoding) {
+		respondError(c, http.StatusBadRequest, errBadRequest, "encoding must be text or binary")
 		return
 	}
 
 	// Check if key exists first
 	existsCount, err := client.Exists(c, key).Result()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to check key existence: %v", err)})
+		respondRedisError(c, err, fmt.Sprintf("Failed to check key existence: %v", err))
 		return
 	}
 	if existsCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Key '%s' does not exist", key)})
+		respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
 		return
 	}
+	recordKeyAccess(id, db, key)
 
 	// Get key type
 	keyType, err := client.Type(c, key).Result()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondRedisError(c, err, err.Error())
 		return
 	}
 
-	var value interface{}
-	switch keyType {
-	case "string":
-		val, err := client.Get(c, key).Result()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// RedisBloom keys have no value to read, their INFO describes them
+	if probabilistic, ok := probabilisticTypes[keyType]; ok {
+		if !requireBloomModule(c, id) {
 			return
 		}
-		// Try to parse as JSON first
-		var jsonValue interface{}
-		if err := json.Unmarshal([]byte(val), &jsonValue); err == nil {
-			value = jsonValue
-		} else {
-			// If not JSON, check if it's binary data
-			if isBinary(val) {
-				value = map[string]interface{}{
-					"type": "binary",
-					"data": base64.StdEncoding.EncodeToString([]byte(val)),
-				}
-			} else {
-				value = val
-			}
-		}
-	case "list":
-		val, err := client.LRange(c, key, 0, -1).Result()
+		info, err := readProbabilisticInfo(c, client, key, probabilistic.prefix)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			respondBloomError(c, err)
 			return
 		}
-		// Try to parse each item as JSON or handle binary data
-		parsedList := make([]interface{}, len(val))
-		for i, item := range val {
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(item), &jsonValue); err == nil {
-				parsedList[i] = jsonValue
-			} else if isBinary(item) {
-				parsedList[i] = map[string]interface{}{
-					"type": "binary",
-					"data": base64.StdEncoding.EncodeToString([]byte(item)),
-				}
-			} else {
-				parsedList[i] = item
+		c.JSON(http.StatusOK, gin.H{"type": keyType, "kind": probabilistic.kind, "info": info})
+		return
+	}
+
+	if encoding != "" && keyType == "string" {
+		raw, err := client.Get(c, key).Result()
+		if err != nil {
+			if isWrongType(err) {
+				respondWrongType(c, client, key, keyType)
+				return
 			}
+			respondRedisError(c, err, err.Error())
+			return
 		}
-		value = parsedList
-	case "set":
-		val, err := client.SMembers(c, key).Result()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusOK, gin.H{"type": keyType, "value": decodeValueAs(raw, encoding)})
+		return
+	}
+
+	pipe := client.Pipeline()
+	read := queueValueRead(c, pipe, key, keyType)
+	if read == nil {
+		respondError(c, http.StatusBadRequest, errUnsupportedType, "Unsupported key type")
+		return
+	}
+	// The reader reports the command's error, including WRONGTYPE
+	pipe.Exec(c)
+	value, err := read()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, keyType)
 			return
 		}
-		// Try to parse each item as JSON or handle binary data
-		parsedSet := make([]interface{}, len(val))
-		for i, item := range val {
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(item), &jsonValue); err == nil {
-				parsedSet[i] = jsonValue
-			} else if isBinary(item) {
-				parsedSet[i] = map[string]interface{}{
-					"type": "binary",
-					"data": base64.StdEncoding.EncodeToString([]byte(item)),
-				}
-			} else {
-				parsedSet[i] = item
-			}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	result := gin.H{
+		"type":  keyType,
+		"value": value,
+	}
+	if keyType == "hash" {
+		if fieldTTLs := expiringHashFields(c, id, client, key, value); fieldTTLs != nil {
+			result["fieldTtls"] = fieldTTLs
 		}
-		value = parsedSet
-	case "hash":
-		val, err := client.HGetAll(c, key).Result()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// expiringHashFields returns the TTL of the fields of a hash that expire, or
+// nil when the server has no field expiry. It is best effort: getKey still
+// returns the value if the TTLs cannot be read.
+func expiringHashFields(c *gin.Context, id string, client *redis.Client, key string, value interface{}) map[string]gin.H {
+	server, _ := getConnection(id)
+	info, err := serverInfoFor(c, id, server)
+	if err != nil || !info.Capabilities.SupportsHashFieldTTL {
+		return nil
+	}
+	hash, _ := value.(map[string]interface{})
+	ttls, err := readHashFieldTTLs(c, client, key, mapKeys(hash))
+	if err != nil {
+		return nil
+	}
+	expiring := make(map[string]gin.H)
+	for field, pttl := range ttls {
+		if pttl >= 0 {
+			expiring[field] = fieldTTLInfo(pttl)
 		}
-		// Try to parse each value as JSON or handle binary data
-		parsedHash := make(map[string]interface{})
-		for k, v := range val {
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(v), &jsonValue); err == nil {
-				parsedHash[k] = jsonValue
-			} else if isBinary(v) {
-				parsedHash[k] = map[string]interface{}{
-					"type": "binary",
-					"data": base64.StdEncoding.EncodeToString([]byte(v)),
-				}
-			} else {
-				parsedHash[k] = v
-			}
+	}
+	return expiring
+}
+
+// decodeValue converts a raw Redis string into its JSON form: parsed JSON when
+// it is valid JSON, a base64 {type: "binary"} object when it contains binary
+// data, and the plain string otherwise.
+func decodeValue(raw string) interface{} {
+	var jsonValue interface{}
+	if err := json.Unmarshal([]byte(raw), &jsonValue); err == nil {
+		return jsonValue
+	}
+	if isBinary(raw) {
+		return binaryValue(raw)
+	}
+	return raw
+}
+
+// decodeValueAs is decodeValue with an encoding hint: "text" returns the raw
+// string as is and "binary" always returns the base64 object.
+func decodeValueAs(raw, encoding string) interface{} {
+	switch encoding {
+	case "text":
+		return raw
+	case "binary":
+		return binaryValue(raw)
+	default:
+		return decodeValue(raw)
+	}
+}
+
+func binaryValue(raw string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "binary",
+		"data": base64.StdEncoding.EncodeToString([]byte(raw)),
+	}
+}
+
+// validEncoding reports whether encoding is a known encoding hint; empty
+// means none.
+func validEncoding(encoding string) bool {
+	return encoding == "" || encoding == "text" || encoding == "binary"
+}
+
+// stringValueAs is stringValue with an encoding hint: "text" requires a
+// string and "binary" a base64 string or {type: "binary"} object.
+func stringValueAs(v interface{}, encoding string) (string, error) {
+	switch encoding {
+	case "text":
+		str, ok := v.(string)
+		if !ok {
+			return "", errors.New("encoding text needs a string value")
 		}
-		value = parsedHash
-	case "zset":
-		val, err := client.ZRangeWithScores(c, key, 0, -1).Result()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+		return str, nil
+	case "binary":
+		if str, ok := v.(string); ok {
+			v = map[string]interface{}{"type": "binary", "data": str}
 		}
-		// Convert to a more readable format and handle binary data
-		zsetValue := make([]map[string]interface{}, len(val))
-		for i, z := range val {
-			memberStr := fmt.Sprintf("%v", z.Member)
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(memberStr), &jsonValue); err == nil {
-				zsetValue[i] = map[string]interface{}{
-					"score":  z.Score,
-					"member": jsonValue,
-				}
-			} else if isBinary(memberStr) {
-				zsetValue[i] = map[string]interface{}{
-					"score": z.Score,
-					"member": map[string]interface{}{
-						"type": "binary",
-						"data": base64.StdEncoding.EncodeToString([]byte(memberStr)),
-					},
-				}
-			} else {
-				zsetValue[i] = map[string]interface{}{
-					"score":  z.Score,
-					"member": memberStr,
-				}
-			}
+		if obj, ok := v.(map[string]interface{}); !ok || obj["type"] != "binary" {
+			return "", errors.New("encoding binary needs a base64 string value")
 		}
-		value = zsetValue
+		return encodeValue(v)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported key type"})
-		return
+		return stringValue(v)
 	}
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"type":  keyType,
-		"value": value,
-	})
+// stringValue converts a JSON request value into the string stored in Redis.
+// Strings are stored as-is, anything else is stored as its JSON encoding.
+func stringValue(v interface{}) (string, error) {
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
 }
 
-// Helper function to check if a string contains binary data
+// isBinary reports whether s is not text: invalid UTF-8, or UTF-8 holding
+// control characters other than tab, newline and carriage return. Accented
+// letters, CJK and emoji are text.
 func isBinary(s string) bool {
-	for _, b := range []byte(s) {
-		if b < 32 || b > 126 {
+	if !utf8.ValidString(s) {
+		return true
+	}
+	for _, r := range s {
+		if (r < 32 && r != '\t' && r != '\n' && r != '\r') || r == 0x7f {
 			return true
 		}
 	}
 	return false
 }
 
+// resolveKeyTTL picks the TTL setKey writes a key with, 0 meaning none:
+//   - a positive requested TTL, in seconds with millisecond precision;
+//   - 0 or -1 requested: no expiry, even with a connection default;
+//   - none requested: the current TTL of the key when it has one, no expiry
+//     for an existing persistent key, and the connection's defaultTTL for a
+//     new key. current is the PTTL reply: -2 for a missing key, -1 for one
+//     without expiry.
+func resolveKeyTTL(requested *float64, current, defaultTTL time.Duration) time.Duration {
+	switch {
+	case requested != nil && *requested > 0:
+		return max(time.Duration(*requested*1000)*time.Millisecond, time.Millisecond)
+	case requested != nil:
+		return 0
+	case current > 0:
+		return current
+	case current == -2:
+		// PTTL of a key that does not exist
+		return defaultTTL
+	}
+	return 0
+}
+
+// maxValueBytes caps the request body of setKey, so a huge value is rejected
+// before it is decoded into memory.
+var maxValueBytes = envInt("WEBREDIS_MAX_VALUE_BYTES", 64<<20)
+
+// maxWaitTimeout caps how long setKey may block in WAIT.
+const maxWaitTimeout = time.Minute
+
 func setKey(c *gin.Context) {
 	id := c.Param("id")
 	db := c.Param("db")
 	key := c.Param("key")
-	client, exists := connections[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
-		return
-	}
-
-	// Select database
-	if err := client.Do(c, "SELECT", db).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to select database: %v", err)})
+	client, ok := clientForDB(c, id, db)
+	if !ok {
 		return
 	}
 
 	var data struct {
 		Type  string      `json:"type"`
 		Value interface{} `json:"value"`
-		TTL   float64     `json:"ttl"` // Change to float64 to handle floating-point values
+		TTL   *float64    `json:"ttl"` // seconds, see resolveKeyTTL
+
+		// Encoding forces how a string value is read: "text" stores the
+		// string as is, "binary" decodes it from base64
+		Encoding string `json:"encoding"`
+
+		// With waitReplicas, WAIT blocks after the write until that many
+		// replicas acknowledged it or waitTimeoutMs passed
+		WaitReplicas  int   `json:"waitReplicas"`
+		WaitTimeoutMs int64 `json:"waitTimeoutMs"`
+
+		// With waitAofLocal (0 or 1) or waitAofReplicas, WAITAOF blocks
+		// the same way until the write is fsynced to the AOF (Redis 7.2+)
+		WaitAOFLocal    int `json:"waitAofLocal"`
+		WaitAOFReplicas int `json:"waitAofReplicas"`
 	}
 
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxValueBytes))
 	if err := c.ShouldBindJSON(&data); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Value exceeds %d bytes", maxValueBytes))
+			return
+		}
 		log.Printf("Error binding JSON: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request data: %v", err)})
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !validEncoding(data.Encoding) {
+		respondError(c, http.StatusBadRequest, errBadRequest, "encoding must be text or binary")
+		return
+	}
+	if data.Encoding != "" && data.Type != "string" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "encoding only applies to string values")
+		return
+	}
+	if data.WaitReplicas < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "waitReplicas must not be negative")
+		return
+	}
+	if data.WaitAOFLocal != 0 && data.WaitAOFLocal != 1 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "waitAofLocal must be 0 or 1")
+		return
+	}
+	if data.WaitAOFReplicas < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "waitAofReplicas must not be negative")
+		return
+	}
+	waitAOF := data.WaitAOFLocal > 0 || data.WaitAOFReplicas > 0
+	waitTimeout := time.Duration(data.WaitTimeoutMs) * time.Millisecond
+	if (data.WaitReplicas > 0 || waitAOF) && (waitTimeout <= 0 || waitTimeout > maxWaitTimeout) {
+		// WAIT with timeout 0 would block forever
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("waitTimeoutMs must be between 1 and %d", maxWaitTimeout.Milliseconds()))
 		return
 	}
 
-	// Convert TTL to integer seconds, ensuring non-negative value
-	ttlSeconds := time.Duration(math.Max(0, math.Floor(data.TTL))) * time.Second
+	if waitAOF {
+		if info, err := serverInfoFor(c, id, client); err == nil && !info.Capabilities.SupportsWaitAOF {
+			respondError(c, http.StatusNotImplemented, errNotSupported, fmt.Sprintf("WAITAOF requires Redis 7.2 or newer, the server runs %s", info.Version))
+			return
+		}
+	}
+
+	// WAIT and WAITAOF only cover the writes made on their own connection,
+	// so when either is requested every write goes through one dedicated
+	// connection, whose read timeout outlasts waitTimeoutMs
+	var writer redis.Cmdable = client
+	var conn *redis.Conn
+	if data.WaitReplicas > 0 || waitAOF {
+		conn = withCommandTimeout(c, client, waitTimeout+time.Second).Conn()
+		defer conn.Close()
+		writer = conn
+	}
+
+	// Without a TTL in the request the key keeps its current expiry, which
+	// the rebuild below would otherwise drop
+	var current time.Duration
+	if data.TTL == nil {
+		var err error
+		current, err = client.PTTL(c, key).Result()
+		if err != nil {
+			respondRedisError(c, err, fmt.Sprintf("Failed to read current TTL: %v", err))
+			return
+		}
+	}
+	ttl := resolveKeyTTL(data.TTL, current, defaultTTLFor(id))
 
 	var err error
 	switch data.Type {
 	case "string":
 		// Try to convert the value to a string
-		var strValue string
-		switch v := data.Value.(type) {
-		case string:
-			strValue = v
-		default:
-			// Try to marshal non-string values to JSON
-			jsonBytes, err := json.Marshal(v)
-			if err != nil {
-				log.Printf("Error marshaling value to JSON: %v", err)
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to convert value to string"})
-				return
-			}
-			strValue = string(jsonBytes)
-		}
-		err = client.Set(c, key, strValue, ttlSeconds).Err()
-	case "list":
-		values := data.Value.([]interface{})
-		// Delete existing list first
-		if err := client.Del(c, key).Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to clear existing list: %v", err)})
+		strValue, convErr := stringValueAs(data.Value, data.Encoding)
+		if convErr != nil {
+			log.Printf("Error marshaling value to JSON: %v", convErr)
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Failed to convert value to string: %v", convErr))
 			return
 		}
-		for _, v := range values {
-			err = client.RPush(c, key, v).Err()
-			if err != nil {
-				break
-			}
-		}
-	case "set":
-		values := data.Value.([]interface{})
-		// Delete existing set first
-		if err := client.Del(c, key).Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to clear existing set: %v", err)})
+		// SET with an expiry stores the value and its TTL in one atomic
+		// command; ttl is 0 for none
+		err = writer.Set(c, key, strValue, ttl).Err()
+	case "list", "set", "hash", "zset":
+		// Replace the existing key and write the elements in chunks, all in
+		// one pipeline
+		pipe := writer.Pipeline()
+		if convErr := queueValueWrite(c, pipe, key, data.Type, data.Value); convErr != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, convErr.Error())
 			return
 		}
-		for _, v := range values {
-			err = client.SAdd(c, key, v).Err()
-			if err != nil {
-				break
-			}
-		}
-	case "hash":
-		values := data.Value.(map[string]interface{})
-		// Delete existing hash first
-		if err := client.Del(c, key).Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to clear existing hash: %v", err)})
-			return
-		}
-		for k, v := range values {
-			err = client.HSet(c, key, k, v).Err()
-			if err != nil {
-				break
-			}
-		}
-	case "zset":
-		values := data.Value.([]interface{})
-		// Delete existing zset first
-		if err := client.Del(c, key).Err(); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to clear existing zset: %v", err)})
-			return
-		}
-		for _, v := range values {
-			item := v.(map[string]interface{})
-			err = client.ZAdd(c, key, redis.Z{
-				Score:  item["score"].(float64),
-				Member: item["member"],
-			}).Err()
-			if err != nil {
-				break
-			}
-		}
+		_, err = pipe.Exec(c)
 	default:
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported key type"})
+		respondError(c, http.StatusBadRequest, errUnsupportedType, "Unsupported key type")
 		return
 	}
 
 	if err != nil {
 		log.Printf("Error setting key: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to set key: %v", err)})
+		respondRedisError(c, err, fmt.Sprintf("Failed to set key: %v", err))
 		return
 	}
+	invalidateKeyCount(id, db)
 
-	// Set TTL for non-string types
-	if data.Type != "string" && ttlSeconds > 0 {
-		err = client.Expire(c, key, ttlSeconds).Err()
+	// Set TTL for non-string types. Redis has no atomic "create collection
+	// with expiry" command, so this stays a separate EXPIRE after the writes.
+	if data.Type != "string" && ttl > 0 {
+		err = writer.PExpire(c, key, ttl).Err()
 		if err != nil {
 			log.Printf("Error setting TTL: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to set TTL: %v", err)})
+			respondRedisError(c, err, fmt.Sprintf("Failed to set TTL: %v", err))
 			return
 		}
 	}
 
+	if conn != nil {
+		result := gin.H{}
+		var warnings []string
+		if data.WaitReplicas > 0 {
+			acked, err := conn.Wait(c, data.WaitReplicas, waitTimeout).Result()
+			if err != nil {
+				respondRedisError(c, err, fmt.Sprintf("Key was written but WAIT failed: %v", err))
+				return
+			}
+			result["replicasRequested"] = data.WaitReplicas
+			result["replicasAcked"] = acked
+			if acked < int64(data.WaitReplicas) {
+				warnings = append(warnings, fmt.Sprintf("Only %d of %d replicas acknowledged the write within %dms", acked, data.WaitReplicas, data.WaitTimeoutMs))
+			}
+		}
+		if waitAOF {
+			acked, err := runOnConn(c, conn, "WAITAOF", data.WaitAOFLocal, data.WaitAOFReplicas, data.WaitTimeoutMs).Int64Slice()
+			if err == nil && len(acked) != 2 {
+				err = fmt.Errorf("unexpected reply %v", acked)
+			}
+			if err != nil {
+				if strings.Contains(err.Error(), "appendonly is disabled") {
+					respondError(c, http.StatusConflict, errConflict, "Key was written but AOF is disabled on this server (appendonly no), so waitAofLocal must be 0")
+					return
+				}
+				respondRedisError(c, err, fmt.Sprintf("Key was written but WAITAOF failed: %v", err))
+				return
+			}
+			result["aofLocalAcked"] = acked[0]
+			result["aofReplicasAcked"] = acked[1]
+			if acked[0] < int64(data.WaitAOFLocal) || acked[1] < int64(data.WaitAOFReplicas) {
+				warnings = append(warnings, fmt.Sprintf("Only %d of %d local and %d of %d replica AOF fsyncs were acknowledged within %dms", acked[0], data.WaitAOFLocal, acked[1], data.WaitAOFReplicas, data.WaitTimeoutMs))
+			}
+		}
+		if len(warnings) > 0 {
+			result["warning"] = strings.Join(warnings, "; ")
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
 	c.Status(http.StatusOK)
 }
 
+// conditionalDeleteScript deletes a key only when its type is ARGV[1] (when
+// not empty) and, when ARGV[2] is "1", its string value equals ARGV[3]. It
+// replies {outcome, type} where outcome is "deleted", "missing", "type" or
+// "value", so the checks and the DEL happen atomically.
+var conditionalDeleteScript = redis.NewScript(`
+local t = redis.call('TYPE', KEYS[1])['ok']
+if t == 'none' then
+	return {'missing', t}
+end
+if ARGV[1] ~= '' and t ~= ARGV[1] then
+	return {'type', t}
+end
+if ARGV[2] == '1' then
+	if t ~= 'string' then
+		return {'type', t}
+	end
+	if redis.call('GET', KEYS[1]) ~= ARGV[3] then
+		return {'value', t}
+	end
+end
+redis.call('DEL', KEYS[1])
+return {'deleted', t}
+`)
+
+// deleteKey deletes a key. ?expectedType only deletes a key of that type and
+// ?expectedValue only a string key holding that value; either condition
+// failing is a 409 and leaves the key in place.
 func deleteKey(c *gin.Context) {
 	id := c.Param("id")
 	db := c.Param("db")
 	key := c.Param("key")
-	client, exists := connections[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+	client, ok := clientForDB(c, id, db)
+	if !ok {
 		return
 	}
 
-	// Select database
-	if err := client.Do(c, "SELECT", db).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to select database: %v", err)})
+	expectedType := strings.ToLower(c.Query("expectedType"))
+	expectedValue, compareValue := c.GetQuery("expectedValue")
+	if expectedType != "" || compareValue {
+		deleteKeyIf(c, client, id, db, key, expectedType, expectedValue, compareValue)
 		return
 	}
 
-	if err := client.Del(c, key).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	deleted, err := client.Del(c, key).Result()
+	if err != nil {
+		respondRedisError(c, err, err.Error())
 		return
 	}
+	adjustKeyCount(id, db, -deleted)
 
 	c.Status(http.StatusOK)
 }
 
-func executeCommand(c *gin.Context) {
-	id := c.Param("id")
-	db := c.Param("db")
-	client, exists := connections[id]
-	if !exists {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Connection not found"})
+func deleteKeyIf(c *gin.Context, client *redis.Client, id, db, key, expectedType, expectedValue string, compareValue bool) {
+	switch expectedType {
+	case "", "string", "list", "set", "zset", "hash", "stream":
+	default:
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Unknown expectedType '%s'", expectedType))
+		return
+	}
+	if compareValue && expectedType != "" && expectedType != "string" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "expectedValue only applies to string keys")
 		return
 	}
 
-	// Select database
-	if err := client.Do(c, "SELECT", db).Err(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to select database: %v", err)})
+	flag := "0"
+	if compareValue {
+		flag = "1"
+	}
+	reply, err := conditionalDeleteScript.Run(c, client, []string{key}, expectedType, flag, expectedValue).StringSlice()
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to delete key: %v", err))
+		return
+	}
+
+	outcome, current := reply[0], reply[1]
+	switch outcome {
+	case "missing":
+		respondError(c, http.StatusNotFound, errKeyNotFound, "Key not found")
+	case "type":
+		expected := expectedType
+		if expected == "" {
+			expected = "string"
+		}
+		respondError(c, http.StatusConflict, errWrongType, fmt.Sprintf("key is of type %s, expected %s", current, expected))
+	case "value":
+		respondError(c, http.StatusConflict, errConflict, "Key value does not match expectedValue, not deleted")
+	default:
+		adjustKeyCount(id, db, -1)
+		c.Status(http.StatusOK)
+	}
+}
+
+// Limits for the raw command console, the most dangerous endpoint there is.
+var (
+	executeMaxArgs  = envInt("WEBREDIS_EXECUTE_MAX_ARGS", 1024)
+	executeMaxBytes = envInt("WEBREDIS_EXECUTE_MAX_BYTES", 1<<20)
+)
+
+func executeCommand(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
 		return
 	}
 
 	var data struct {
 		Command string   `json:"command"`
 		Args    []string `json:"args"`
+
+		// TimeoutMs overrides the connection's command timeout for this
+		// command, up to WEBREDIS_MAX_REQUEST_TIMEOUT
+		TimeoutMs int64 `json:"timeoutMs"`
 	}
 
+	// Refuse oversized commands before they are read into memory
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(executeMaxBytes))
 	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Command exceeds %d bytes", executeMaxBytes))
+			return
+		}
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	if len(data.Args) > executeMaxArgs {
+		respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Command has %d arguments, the limit is %d", len(data.Args), executeMaxArgs))
+		return
+	}
+	size := len(data.Command)
+	for _, arg := range data.Args {
+		size += len(arg)
+	}
+	if size > executeMaxBytes {
+		respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Command exceeds %d bytes", executeMaxBytes))
+		return
+	}
+
+	// The database is part of the URL; SELECT would switch a pooled
+	// connection that other requests share.
+	if strings.EqualFold(data.Command, "SELECT") {
+		respondError(c, http.StatusBadRequest, errBadRequest, "SELECT is not supported, choose the database in the URL instead")
 		return
 	}
 
+	// Reads stay open; anything that can change data or the server needs
+	// admin mode
+	if !adminMode || adminToken != "" {
+		if commandNeedsAdmin(c, client, data.Command, data.Args) && !requireAdmin(c) {
+			return
+		}
+	}
+
+	if data.TimeoutMs < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "timeoutMs must not be negative")
+		return
+	}
+	var ctx context.Context = c
+	timeout := time.Duration(data.TimeoutMs) * time.Millisecond
+	if timeout > 0 {
+		timeout = min(timeout, maxRequestTimeout)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		client = withCommandTimeout(c, client, timeout)
+	}
+
 	// Convert args to interface{} for Redis command
 	args := make([]interface{}, len(data.Args)+1)
 	args[0] = data.Command
 	for i, arg := range data.Args {
 		args[i+1] = arg
 	}
+	args, ok = requireCommandInScope(c, client, id, args)
+	if !ok {
+		return
+	}
 
 	// Execute command
-	result, err := client.Do(c, args...).Result()
+	result, err := client.Do(ctx, args...).Result()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if timeout > 0 && isTimeout(err) {
+			respondError(c, http.StatusGatewayTimeout, errRedisTimeout, fmt.Sprintf("Command timed out after %dms", timeout.Milliseconds()))
+			return
+		}
+		respondRedisError(c, err, err.Error())
 		return
 	}
+	invalidateRepliesAfter(id, data.Command, data.Args)
 
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, gin.H{"result": normalizeReply(result)})
 }
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestResolveKeyTTL(t *testing.T) {
+	seconds := func(v float64) *float64 { return &v }
+	const defaultTTL = time.Hour
+
+	tests := []struct {
+		name       string
+		requested  *float64
+		current    time.Duration
+		defaultTTL time.Duration
+		want       time.Duration
+	}{
+		{"default applied to a new key", nil, -2, defaultTTL, defaultTTL},
+		{"no default, new key", nil, -2, 0, 0},
+		{"current TTL kept", nil, 90 * time.Second, defaultTTL, 90 * time.Second},
+		{"existing persistent key stays persistent", nil, -1, defaultTTL, 0},
+		{"explicit 0 overrides the default", seconds(0), -2, defaultTTL, 0},
+		{"explicit -1 overrides the default", seconds(-1), -2, defaultTTL, 0},
+		{"explicit 0 removes the current TTL", seconds(0), 90 * time.Second, defaultTTL, 0},
+		{"positive TTL wins", seconds(30), 90 * time.Second, defaultTTL, 30 * time.Second},
+		{"fractional TTL keeps milliseconds", seconds(0.5), -2, defaultTTL, 500 * time.Millisecond},
+		{"tiny TTL rounds up to 1ms", seconds(0.0001), -2, defaultTTL, time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveKeyTTL(tt.requested, tt.current, tt.defaultTTL); got != tt.want {
+				t.Errorf("resolveKeyTTL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveConnectionAgainReleasesPreviousClient(t *testing.T) {
+	const id = "test-resave"
+	first := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	second := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer removeConnection(id)
+
+	if _, replaced := setConnection(id, first); replaced {
+		t.Fatal("first save replaced a client")
+	}
+	setKeyPrefix(id, "app:")
+	previous, replaced := setConnection(id, second)
+	if !replaced || previous != first {
+		t.Fatalf("second save replaced %v, %v, want the first client", previous, replaced)
+	}
+	releaseConnection(id, previous)
+
+	if err := first.Ping(context.Background()).Err(); err != redis.ErrClosed {
+		t.Errorf("previous client not closed: %v", err)
+	}
+	if client, _ := getConnection(id); client != second {
+		t.Error("new client not stored")
+	}
+	if keyPrefixFor(id) != "app:" {
+		t.Error("releasing the previous client dropped the key prefix")
+	}
+	forgetKeyPrefix(id)
+}
+
+func TestClientForDBCache(t *testing.T) {
+	const id = "test-dbclients"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"}))
+	defer func() {
+		client, _ := removeConnection(id)
+		client.Close()
+		closeDBClients(id)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/", nil)
+
+	one, _ := clientForDB(c, id, "1")
+	padded, _ := clientForDB(c, id, "01")
+	if one != padded {
+		t.Error(`"1" and "01" got different clients`)
+	}
+
+	for db := 0; db < maxDBClients+5; db++ {
+		clientForDB(c, id, strconv.Itoa(db))
+	}
+	dbClientsMu.Lock()
+	cached := countDBClients(id)
+	dbClientsMu.Unlock()
+	if cached != maxDBClients {
+		t.Errorf("%d database clients cached, want %d", cached, maxDBClients)
+	}
+	closeRequestClients(c)
+}
+
+var (
+	emojiText  = "deploy 🚀 done ✅"
+	cjkText    = "日本語のテキスト, 中文, 한국어"
+	binaryBlob = "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"empty", "", false},
+		{"ascii", "hello world", false},
+		{"accented", "crème brûlée", false},
+		{"emoji", emojiText, false},
+		{"cjk", cjkText, false},
+		{"tabs and newlines", "a\tb\r\nc", false},
+		{"png header", binaryBlob, true},
+		{"invalid utf-8", "caf\xe9", true},
+		{"truncated emoji", emojiText[:9], true},
+		{"nul byte", "a\x00b", true},
+		{"delete", "a\x7fb", true},
+	}
+	for _, tt := range tests {
+		if got := isBinary(tt.s); got != tt.want {
+			t.Errorf("%s: isBinary(%q) = %v, want %v", tt.name, tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeValueAs(t *testing.T) {
+	binary := binaryValue(binaryBlob)
+	tests := []struct {
+		name     string
+		raw      string
+		encoding string
+		want     interface{}
+	}{
+		{"emoji", emojiText, "", emojiText},
+		{"cjk", cjkText, "", cjkText},
+		{"binary detected", binaryBlob, "", binary},
+		{"json", `{"a":1}`, "", map[string]interface{}{"a": float64(1)}},
+		{"json as text", `{"a":1}`, "text", `{"a":1}`},
+		{"binary as text", binaryBlob, "text", binaryBlob},
+		{"cjk as binary", cjkText, "binary", binaryValue(cjkText)},
+	}
+	for _, tt := range tests {
+		if got := decodeValueAs(tt.raw, tt.encoding); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decodeValueAs(%q, %q) = %v, want %v", tt.name, tt.raw, tt.encoding, got, tt.want)
+		}
+	}
+}
+
+func TestStringValueAs(t *testing.T) {
+	binary := binaryValue(binaryBlob)
+	tests := []struct {
+		name     string
+		value    interface{}
+		encoding string
+		want     string
+		wantErr  bool
+	}{
+		{"emoji", emojiText, "", emojiText, false},
+		{"cjk as text", cjkText, "text", cjkText, false},
+		{"json object", map[string]interface{}{"a": float64(1)}, "", `{"a":1}`, false},
+		{"binary object", binary, "binary", binaryBlob, false},
+		{"base64 string", binary["data"], "binary", binaryBlob, false},
+		{"number as text", float64(1), "text", "", true},
+		{"invalid base64", "not base64!", "binary", "", true},
+		{"number as binary", float64(1), "binary", "", true},
+	}
+	for _, tt := range tests {
+		got, err := stringValueAs(tt.value, tt.encoding)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: stringValueAs(%v, %q) = %q, %v, want %q (error: %v)", tt.name, tt.value, tt.encoding, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	for _, raw := range []string{emojiText, cjkText, binaryBlob} {
+		for _, encoding := range []string{"text", "binary"} {
+			stored, err := stringValueAs(decodeValueAs(raw, encoding), encoding)
+			if err != nil || stored != raw {
+				t.Errorf("round trip of %q as %s gave %q, %v", raw, encoding, stored, err)
+			}
+		}
+	}
+}
+
+func TestKeyListingEntryDropsExpiredKeys(t *testing.T) {
+	// Each case is a key SCAN returned, with the replies read afterwards
+	tests := []struct {
+		name    string
+		keyType string
+		typeErr error
+		pttl    time.Duration
+		pttlErr error
+		want    bool
+	}{
+		{"persistent", "string", nil, -1, nil, true},
+		{"with ttl", "hash", nil, 1500 * time.Millisecond, nil, true},
+		{"expired before TYPE", "none", nil, -2, nil, false},
+		{"expired between PTTL and TYPE", "none", nil, 10 * time.Millisecond, nil, false},
+		{"expired between TYPE and PTTL", "list", nil, -2, nil, false},
+		{"PTTL failed", "set", nil, 0, errors.New("timeout"), true},
+		{"TYPE failed", "", errors.New("timeout"), -1, nil, true},
+	}
+	for _, tt := range tests {
+		entry := keyListingEntry("k", tt.keyType, tt.typeErr, tt.pttl, tt.pttlErr)
+		if (entry != nil) != tt.want {
+			t.Errorf("%s: entry = %v, want kept: %v", tt.name, entry, tt.want)
+		}
+	}
+
+	entries := []map[string]interface{}{
+		keyListingEntry("a", "string", nil, -1, nil),
+		keyListingEntry("gone", "none", nil, -2, nil),
+		keyListingEntry("b", "zset", nil, time.Second, nil),
+	}
+	listed := dropMissingKeys(entries)
+	if len(listed) != 2 || listed[0]["key"] != "a" || listed[1]["key"] != "b" {
+		t.Errorf("dropMissingKeys kept %v, want a and b", listed)
+	}
+}
+
+func TestSetKeyCollectionTTL(t *testing.T) {
+	seconds := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name       string
+		ttl        *float64
+		currentMs  int64 // PTTL reply before the write
+		defaultTTL int
+		wantPTTL   string // PEXPIRE argument, empty for none
+	}{
+		{"edit keeps the expiry", nil, 5000, 0, "5000"},
+		{"edit keeps no expiry", nil, -1, 3600, ""},
+		{"new key gets the default", nil, -2, 60, "60000"},
+		{"explicit ttl replaces it", seconds(30), 5000, 0, "30000"},
+		{"fractional ttl", seconds(0.5), -1, 0, "500"},
+		{"explicit -1 drops it", seconds(-1), 5000, 0, ""},
+		{"explicit 0 drops it", seconds(0), -2, 60, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRedis(t, func(args []string) string {
+				if args[0] == "pttl" {
+					return fmt.Sprintf(":%d\r\n", tt.currentMs)
+				}
+				return ":1\r\n"
+			})
+			const id = "test-setkey-ttl"
+			setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+			setDefaultTTL(id, tt.defaultTTL)
+			defer func() {
+				client, _ := removeConnection(id)
+				releaseConnection(id, client)
+				forgetDefaultTTL(id)
+			}()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/key/:id/:db/:key", setKey)
+			body, _ := json.Marshal(map[string]interface{}{"type": "list", "value": []string{"a", "b"}, "ttl": tt.ttl})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/key/"+id+"/0/queue", bytes.NewReader(body)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("setKey returned %d: %s", w.Code, w.Body.String())
+			}
+
+			if pushes := server.received("rpush"); len(pushes) != 1 {
+				t.Errorf("list written with %v", pushes)
+			}
+			if tt.ttl != nil && len(server.received("pttl")) != 0 {
+				t.Error("PTTL read although the request gave a TTL")
+			}
+			pexpires := server.received("pexpire")
+			switch {
+			case tt.wantPTTL == "" && len(pexpires) != 0:
+				t.Errorf("got %v, want no expiry", pexpires)
+			case tt.wantPTTL != "" && (len(pexpires) != 1 || pexpires[0][2] != tt.wantPTTL):
+				t.Errorf("got %v, want PEXPIRE queue %s", pexpires, tt.wantPTTL)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// memorySampleSize is the default and maximum number of keys measured by the
+// memory estimate.
+var memorySampleSize = envInt("WEBREDIS_MEMORY_SAMPLE_SIZE", 1000)
+
+// estimateMemory approximates the memory used by the keys of a database: it
+// measures a SCAN sample with MEMORY USAGE and scales the mean up to DBSIZE.
+// On a connection with a key prefix both the sample and the count are taken
+// under the prefix, so the estimate covers only the keys it sees.
+// The result is an estimate of key and value sizes only. It leaves out server
+// overhead, replication buffers and fragmentation, which MEMORY STATS reports.
+func estimateMemory(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	sample := memorySampleSize
+	if s := c.Query("sample"); s != "" {
+		var err error
+		sample, err = strconv.Atoi(s)
+		if err != nil || sample <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid sample")
+			return
+		}
+		sample = min(sample, memorySampleSize)
+	}
+
+	dbSize, err := countKeysInScope(c, client, id)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to count keys: %v", err))
+		return
+	}
+
+	// SCAN may return a key twice, which would count it twice in the sample
+	var sizes []float64
+	seen := make(map[string]bool)
+	err = forEachKeyBatch(c, client, scopePattern(id, "*"), func(batch []string) error {
+		keys := batch[:0]
+		for _, key := range batch {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if remaining := sample - len(sizes); len(keys) > remaining {
+			keys = keys[:remaining]
+		}
+		cmds := make([]*redis.IntCmd, len(keys))
+		client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.MemoryUsage(c, key)
+			}
+			return nil
+		})
+		for _, cmd := range cmds {
+			size, err := cmd.Result()
+			if errors.Is(err, redis.Nil) {
+				// Deleted since the scan
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read MEMORY USAGE: %w", err)
+			}
+			sizes = append(sizes, float64(size))
+		}
+		if len(sizes) >= sample {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	n := float64(len(sizes))
+	var total float64
+	for _, size := range sizes {
+		total += size
+	}
+	result := gin.H{
+		"dbSize":       dbSize,
+		"keyPrefix":    keyPrefixFor(id),
+		"sampledKeys":  len(sizes),
+		"sampledBytes": int64(total),
+		"note":         "Estimate extrapolated from MEMORY USAGE of a key sample; excludes server overhead and fragmentation",
+	}
+	if n == 0 {
+		result["estimatedBytes"] = 0
+		result["lowBytes"] = 0
+		result["highBytes"] = 0
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	mean := total / n
+	var variance float64
+	for _, size := range sizes {
+		variance += (size - mean) * (size - mean)
+	}
+	if n > 1 {
+		variance /= n - 1
+	}
+	// 95% interval for the total, with the finite population correction since
+	// the sample may cover a good share of a small database
+	population := math.Max(float64(dbSize), n)
+	correction := 1.0
+	if population > 1 {
+		correction = math.Sqrt((population - n) / (population - 1))
+	}
+	margin := 1.96 * math.Sqrt(variance/n) * correction * population
+
+	estimate := mean * population
+	result["estimatedBytes"] = int64(estimate)
+	result["lowBytes"] = int64(math.Max(total, estimate-margin))
+	result["highBytes"] = int64(estimate + margin)
+	result["meanKeyBytes"] = int64(mean)
+	c.JSON(http.StatusOK, result)
+}
+
+// getMemoryStats parses MEMORY STATS into a map. Ratios arrive as strings
+// over RESP2 and are converted to numbers, and the per-database "db.N"
+// entries become nested maps. summary picks the figures most useful for
+// sizing: dataset versus overhead, and fragmentation at the allocator and
+// RSS level.
+func getMemoryStats(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	reply, err := client.Do(c, "MEMORY", "STATS").Result()
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "MEMORY STATS requires Redis 4.0 or newer")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read memory stats: %v", err))
+		return
+	}
+
+	stats := replyMap(reply)
+	for name, value := range stats {
+		switch v := value.(type) {
+		case []interface{}:
+			stats[name] = replyMap(v)
+		case string:
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				stats[name] = n
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": gin.H{
+			"totalAllocatedBytes": stats["total.allocated"],
+			"peakAllocatedBytes":  stats["peak.allocated"],
+			"datasetBytes":        stats["dataset.bytes"],
+			"datasetPercentage":   stats["dataset.percentage"],
+			"overheadBytes":       stats["overhead.total"],
+			"keys":                stats["keys.count"],
+			"bytesPerKey":         stats["keys.bytes-per-key"],
+			"fragmentationRatio":  stats["fragmentation"],
+			"fragmentationBytes":  stats["fragmentation.bytes"],
+			"allocator": gin.H{
+				"allocatedBytes":     stats["allocator.allocated"],
+				"activeBytes":        stats["allocator.active"],
+				"residentBytes":      stats["allocator.resident"],
+				"fragmentationRatio": stats["allocator-fragmentation.ratio"],
+				"fragmentationBytes": stats["allocator-fragmentation.bytes"],
+				"rssRatio":           stats["allocator.rss-ratio"],
+				"rssBytes":           stats["allocator.rss-bytes"],
+				"rssOverheadRatio":   stats["rss-overhead.ratio"],
+				"rssOverheadBytes":   stats["rss-overhead.bytes"],
+			},
+		},
+		"stats": stats,
+	})
+}
+
+// getMemoryDoctor returns the advice of MEMORY DOCTOR as text.
+func getMemoryDoctor(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	advice, err := client.Do(c, "MEMORY", "DOCTOR").Text()
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "MEMORY DOCTOR requires Redis 4.0 or newer")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to run MEMORY DOCTOR: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"advice": advice})
+}
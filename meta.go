@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// lengthCommands maps a key type to the command that reports its size.
+var lengthCommands = map[string]string{
+	"string": "STRLEN",
+	"list":   "LLEN",
+	"set":    "SCARD",
+	"zset":   "ZCARD",
+	"hash":   "HLEN",
+	"stream": "XLEN",
+}
+
+func getKeyMeta(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var (
+		existsCmd *redis.IntCmd
+		typeCmd   *redis.StatusCmd
+		pttlCmd   *redis.DurationCmd
+	)
+	_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		existsCmd = pipe.Exists(c, key)
+		typeCmd = pipe.Type(c, key)
+		pttlCmd = pipe.PTTL(c, key)
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read key metadata: %v", err))
+		return
+	}
+
+	if existsCmd.Val() == 0 {
+		respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+		return
+	}
+
+	keyType := typeCmd.Val()
+	meta := gin.H{
+		"key":    key,
+		"exists": true,
+		"type":   keyType,
+	}
+	addTTLFields(meta, pttlCmd.Val())
+
+	// The length command depends on the type, so it needs a second round trip
+	if command, ok := lengthCommands[keyType]; ok {
+		length, err := client.Do(c, command, key).Int64()
+		if err != nil {
+			if isWrongType(err) {
+				respondWrongType(c, client, key, keyType)
+				return
+			}
+			respondRedisError(c, err, fmt.Sprintf("Failed to read key length: %v", err))
+			return
+		}
+		meta["length"] = length
+	}
+
+	c.JSON(http.StatusOK, meta)
+}
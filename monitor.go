@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// MONITOR streams every command processed by the server back to the client.
+// It is extremely expensive: a busy Redis instance can lose more than half of
+// its throughput while a single MONITOR is attached. Only use it for short
+// debugging sessions, never against production traffic you care about.
+
+// monitorMaxDuration is how long a MONITOR session may run before it is
+// stopped automatically.
+var monitorMaxDuration = envDuration("WEBREDIS_MONITOR_MAX_DURATION", 5*time.Minute)
+
+// webSocketOrigins are the extra origins, such as a dev server on another
+// port, allowed to open WebSockets besides the page's own host. From the
+// comma-separated WEBREDIS_WEBSOCKET_ORIGINS, e.g. "http://localhost:5173".
+var webSocketOrigins = loadWebSocketOrigins(os.Getenv("WEBREDIS_WEBSOCKET_ORIGINS"))
+
+func loadWebSocketOrigins(value string) map[string]bool {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(value, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[strings.ToLower(origin)] = true
+		}
+	}
+	return origins
+}
+
+// upgrader is shared by the MONITOR and keyspace feeds. Browsers send
+// cookies and reach internal servers from any page, so a socket is only
+// opened for pages of webredis itself or of webSocketOrigins. The
+// "webredis" subprotocol is accepted so clients can also offer the admin
+// token one, see requestAdminToken.
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	Subprotocols:    []string{"webredis"},
+	CheckOrigin:     checkWebSocketOrigin,
+}
+
+// checkWebSocketOrigin accepts handshakes without an Origin header, which do
+// not come from a browser, and those whose Origin has the host of the
+// request or is listed in webSocketOrigins.
+func checkWebSocketOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if webSocketOrigins[strings.ToLower(origin)] {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+func monitorCommands(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Failed to upgrade monitor connection: %v", err)
+		return
+	}
+	defer ws.Close()
+
+	// MONITOR takes over the connection it runs on, so use a dedicated client
+	// instead of borrowing one from the shared pool.
+	options := *client.Options()
+	options.PoolSize = 1
+	options.MinIdleConns = 0
+	options.ReadTimeout = -1 // MONITOR replies arrive indefinitely
+	monitorClient := redis.NewClient(&options)
+	defer monitorClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), monitorMaxDuration)
+	defer cancel()
+
+	// Stop as soon as the browser goes away. Reading is also required for the
+	// websocket library to process close and ping frames.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	lines := make(chan string, 100)
+	monitor := monitorClient.Monitor(ctx, lines)
+	if err := monitor.Err(); err != nil {
+		log.Printf("Failed to start MONITOR: %v", err)
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "Failed to start MONITOR"))
+		return
+	}
+	monitor.Start()
+	log.Printf("MONITOR started for connection %s (max %v)", id, monitorMaxDuration)
+
+	defer func() {
+		monitor.Stop()
+		// The reader goroutine inside go-redis may be blocked sending the last
+		// line; drain until it notices the stop.
+		go func() {
+			for {
+				select {
+				case <-lines:
+				case <-time.After(time.Second):
+					return
+				}
+			}
+		}()
+		log.Printf("MONITOR stopped for connection %s", id)
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			if err := ws.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "MONITOR max duration reached"))
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckWebSocketOrigin(t *testing.T) {
+	previous := webSocketOrigins
+	webSocketOrigins = loadWebSocketOrigins("http://localhost:5173, https://Tools.example.com")
+	defer func() { webSocketOrigins = previous }()
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"", true},
+		{"http://webredis.internal:8080", true},
+		{"https://WEBREDIS.internal:8080", true},
+		{"http://localhost:5173", true},
+		{"https://tools.example.com", true},
+		{"http://webredis.internal", false},
+		{"https://evil.example.com", false},
+		{"http://webredis.internal:8080.evil.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "http://webredis.internal:8080/api/keyspace/local/0", nil)
+		if tt.origin != "" {
+			r.Header.Set("Origin", tt.origin)
+		}
+		if got := checkWebSocketOrigin(r); got != tt.want {
+			t.Errorf("Origin %q: allowed = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestRequestAdminToken(t *testing.T) {
+	header := httptest.NewRequest("GET", "/api/monitor/local", nil)
+	header.Header.Set("X-Admin-Token", "secret")
+	if got := requestAdminToken(header); got != "secret" {
+		t.Errorf("token from header = %q", got)
+	}
+
+	socket := httptest.NewRequest("GET", "/api/monitor/local", nil)
+	socket.Header.Set("Connection", "Upgrade")
+	socket.Header.Set("Upgrade", "websocket")
+	socket.Header.Set("Sec-WebSocket-Protocol", "webredis, admin-token.secret")
+	if got := requestAdminToken(socket); got != "secret" {
+		t.Errorf("token from subprotocol = %q", got)
+	}
+
+	// Only handshakes carry the token as a subprotocol
+	plain := httptest.NewRequest("GET", "/api/monitor/local", nil)
+	plain.Header.Set("Sec-WebSocket-Protocol", "admin-token.secret")
+	if got := requestAdminToken(plain); got != "" {
+		t.Errorf("token of a plain request = %q, want none", got)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// outputBufferLimit is the client-output-buffer-limit of one client class. A
+// client is disconnected once its output buffer reaches the hard limit, or
+// stays above the soft limit for the soft seconds. 0 disables a limit.
+type outputBufferLimit struct {
+	HardLimitBytes   int64 `json:"hardLimitBytes"`
+	SoftLimitBytes   int64 `json:"softLimitBytes"`
+	SoftLimitSeconds int64 `json:"softLimitSeconds"`
+}
+
+// outputBufferClasses maps the class names in client-output-buffer-limit to
+// the ones webredis uses. Servers before 5.0 only know "slave", which newer
+// ones still accept, so it is also the name sent with CONFIG SET.
+var outputBufferClasses = map[string]string{
+	"normal":  "normal",
+	"slave":   "replica",
+	"replica": "replica",
+	"pubsub":  "pubsub",
+}
+
+// parseOutputBufferLimits parses "normal 0 0 0 slave 268435456 67108864 60
+// pubsub 33554432 8388608 60" by class.
+func parseOutputBufferLimits(value string) (map[string]outputBufferLimit, error) {
+	fields := strings.Fields(value)
+	if len(fields)%4 != 0 {
+		return nil, fmt.Errorf("unexpected client-output-buffer-limit %q", value)
+	}
+	limits := make(map[string]outputBufferLimit)
+	for i := 0; i < len(fields); i += 4 {
+		class, ok := outputBufferClasses[fields[i]]
+		if !ok {
+			return nil, fmt.Errorf("unknown client class %q in client-output-buffer-limit", fields[i])
+		}
+		var numbers [3]int64
+		for j := range numbers {
+			n, err := strconv.ParseInt(fields[i+1+j], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected client-output-buffer-limit %q", value)
+			}
+			numbers[j] = n
+		}
+		limits[class] = outputBufferLimit{HardLimitBytes: numbers[0], SoftLimitBytes: numbers[1], SoftLimitSeconds: numbers[2]}
+	}
+	return limits, nil
+}
+
+// readOutputBufferLimits reads client-output-buffer-limit by class.
+func readOutputBufferLimits(c *gin.Context, id string, client *redis.Client) (map[string]outputBufferLimit, error) {
+	config, err := cachedReply(c, id, "CONFIG GET client-output-buffer-limit", func() (map[string]string, error) {
+		return client.ConfigGet(c, "client-output-buffer-limit").Result()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseOutputBufferLimits(config["client-output-buffer-limit"])
+}
+
+// getOutputBufferLimits returns the output buffer limits of the normal,
+// replica and pubsub client classes.
+func getOutputBufferLimits(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	limits, err := readOutputBufferLimits(c, id, client)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read output buffer limits: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"limits": limits})
+}
+
+// setOutputBufferLimits changes the limits of the classes in the body, e.g.
+// { "pubsub": { "hardLimitBytes": 33554432, "softLimitBytes": 8388608,
+// "softLimitSeconds": 60 } }, with CONFIG SET. Classes left out keep their
+// limits. Requires admin mode.
+func setOutputBufferLimits(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data map[string]struct {
+		HardLimitBytes   *int64 `json:"hardLimitBytes"`
+		SoftLimitBytes   *int64 `json:"softLimitBytes"`
+		SoftLimitSeconds *int64 `json:"softLimitSeconds"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "At least one of normal, replica and pubsub is required")
+		return
+	}
+
+	// Classes are written in a fixed order so the value is predictable
+	var parts []string
+	for _, class := range []string{"normal", "replica", "pubsub"} {
+		limit, ok := data[class]
+		if !ok {
+			continue
+		}
+		delete(data, class)
+		// A missing number would silently become 0, which means unlimited
+		if limit.HardLimitBytes == nil || limit.SoftLimitBytes == nil || limit.SoftLimitSeconds == nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("%s needs hardLimitBytes, softLimitBytes and softLimitSeconds", class))
+			return
+		}
+		if *limit.HardLimitBytes < 0 || *limit.SoftLimitBytes < 0 || *limit.SoftLimitSeconds < 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("%s limits must not be negative", class))
+			return
+		}
+		name := class
+		if class == "replica" {
+			name = "slave"
+		}
+		parts = append(parts, fmt.Sprintf("%s %d %d %d", name, *limit.HardLimitBytes, *limit.SoftLimitBytes, *limit.SoftLimitSeconds))
+	}
+	if len(data) > 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Unknown client class '%s', expected normal, replica or pubsub", sortedKeys(data)[0]))
+		return
+	}
+
+	if err := client.ConfigSet(c, "client-output-buffer-limit", strings.Join(parts, " ")).Err(); err != nil {
+		if isRedisServerError(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Failed to set output buffer limits: %v", err))
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to set output buffer limits: %v", err))
+		return
+	}
+	invalidateConfigReplies(id, "client-output-buffer-limit")
+
+	limits, err := readOutputBufferLimits(c, id, client)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Limits were set but could not be read back: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"limits": limits})
+}
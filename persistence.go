@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// saveSnapshot starts an RDB snapshot with BGSAVE. With ?sync=true it runs a
+// blocking SAVE instead, which stalls the server until the snapshot is done
+// and may take up to WEBREDIS_MAX_REQUEST_TIMEOUT.
+func saveSnapshot(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	command := "BGSAVE"
+	if c.Query("sync") == "true" {
+		command = "SAVE"
+		// SAVE replies once the snapshot is written, which takes far longer
+		// than the command timeout on any sizeable dataset
+		client = withCommandTimeout(c, client, maxRequestTimeout)
+	}
+
+	result, err := client.Do(c, command).Text()
+	if err != nil {
+		if strings.Contains(err.Error(), "already in progress") {
+			respondError(c, http.StatusConflict, errConflict, "A background save is already in progress")
+			return
+		}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+	invalidateInfoReplies(id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"command": command,
+		"result":  result,
+	})
+}
+
+func getLastSave(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	lastSave, err := client.LastSave(c).Result()
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lastSave": lastSave,
+		"time":     time.Unix(lastSave, 0).UTC().Format(time.RFC3339),
+	})
+}
+
+// waitAOF runs WAITAOF, blocking until the writes made so far on the
+// connection it runs on are fsynced to the local AOF ({ "numlocal": 1 }) and
+// the AOF of { "numreplicas" } replicas, or timeoutMs passes. Requests share
+// pooled connections, so this only covers the writes earlier requests made
+// on the pooled connection WAITAOF happens to use; to confirm a specific
+// write, use the waitAofLocal and waitAofReplicas options of setKey, which
+// run WAITAOF on the connection that made it. Redis 7.2+.
+func waitAOF(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data struct {
+		NumLocal    int   `json:"numlocal"`
+		NumReplicas int   `json:"numreplicas"`
+		TimeoutMs   int64 `json:"timeoutMs"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.NumLocal != 0 && data.NumLocal != 1 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "numlocal must be 0 or 1")
+		return
+	}
+	if data.NumReplicas < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "numreplicas must not be negative")
+		return
+	}
+	// WAITAOF with timeout 0 would block forever
+	timeout := time.Duration(data.TimeoutMs) * time.Millisecond
+	if timeout <= 0 || timeout > maxWaitTimeout {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("timeoutMs must be between 1 and %d", maxWaitTimeout.Milliseconds()))
+		return
+	}
+
+	info, err := serverInfoFor(c, id, client)
+	if err == nil && !info.Capabilities.SupportsWaitAOF {
+		respondError(c, http.StatusNotImplemented, errNotSupported, fmt.Sprintf("WAITAOF requires Redis 7.2 or newer, the server runs %s", info.Version))
+		return
+	}
+
+	// The read timeout of the pool would cut the wait short
+	acked, err := withCommandTimeout(c, client, timeout+time.Second).Do(c, "WAITAOF", data.NumLocal, data.NumReplicas, data.TimeoutMs).Int64Slice()
+	if err != nil {
+		if strings.Contains(err.Error(), "appendonly is disabled") {
+			respondError(c, http.StatusConflict, errConflict, "AOF is disabled on this server (appendonly no), so numlocal must be 0")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("WAITAOF failed: %v", err))
+		return
+	}
+	if len(acked) != 2 {
+		respondError(c, http.StatusInternalServerError, errRedisError, fmt.Sprintf("Unexpected WAITAOF reply %v", acked))
+		return
+	}
+
+	result := gin.H{
+		"numlocal":    acked[0],
+		"numreplicas": acked[1],
+	}
+	if acked[0] < int64(data.NumLocal) || acked[1] < int64(data.NumReplicas) {
+		result["warning"] = fmt.Sprintf("Only %d of %d local and %d of %d replica AOF fsyncs were acknowledged within %dms", acked[0], data.NumLocal, acked[1], data.NumReplicas, data.TimeoutMs)
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// persistenceState is the persistence section of INFO. Times are Unix
+// seconds; statuses are "ok" or "err". The loading fields are only set while
+// the server loads its dataset.
+type persistenceState struct {
+	Loading                 bool    `json:"loading"`
+	LoadingLoadedPercent    float64 `json:"loadingLoadedPercent,omitempty"`
+	LoadingEtaSeconds       int64   `json:"loadingEtaSeconds,omitempty"`
+	RDBChangesSinceLastSave int64   `json:"rdbChangesSinceLastSave"`
+	RDBBgsaveInProgress     bool    `json:"rdbBgsaveInProgress"`
+	RDBLastSaveTime         int64   `json:"rdbLastSaveTime"`
+	RDBLastBgsaveStatus     string  `json:"rdbLastBgsaveStatus"`
+	AOFEnabled              bool    `json:"aofEnabled"`
+	AOFRewriteInProgress    bool    `json:"aofRewriteInProgress"`
+	AOFRewriteScheduled     bool    `json:"aofRewriteScheduled"`
+	AOFLastBgrewriteStatus  string  `json:"aofLastBgrewriteStatus"`
+	AOFLastWriteStatus      string  `json:"aofLastWriteStatus"`
+}
+
+// parsePersistenceState reads lines such as "rdb_changes_since_last_save:12".
+func parsePersistenceState(raw string) persistenceState {
+	var state persistenceState
+	for _, line := range strings.Split(raw, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		switch name {
+		case "loading":
+			state.Loading = value == "1"
+		case "loading_loaded_perc":
+			state.LoadingLoadedPercent, _ = strconv.ParseFloat(value, 64)
+		case "loading_eta_seconds":
+			state.LoadingEtaSeconds, _ = strconv.ParseInt(value, 10, 64)
+		case "rdb_changes_since_last_save":
+			state.RDBChangesSinceLastSave, _ = strconv.ParseInt(value, 10, 64)
+		case "rdb_bgsave_in_progress":
+			state.RDBBgsaveInProgress = value == "1"
+		case "rdb_last_save_time":
+			state.RDBLastSaveTime, _ = strconv.ParseInt(value, 10, 64)
+		case "rdb_last_bgsave_status":
+			state.RDBLastBgsaveStatus = value
+		case "aof_enabled":
+			state.AOFEnabled = value == "1"
+		case "aof_rewrite_in_progress":
+			state.AOFRewriteInProgress = value == "1"
+		case "aof_rewrite_scheduled":
+			state.AOFRewriteScheduled = value == "1"
+		case "aof_last_bgrewrite_status":
+			state.AOFLastBgrewriteStatus = value
+		case "aof_last_write_status":
+			state.AOFLastWriteStatus = value
+		}
+	}
+	return state
+}
+
+// getPersistenceState returns the RDB, AOF and loading state of a server from
+// INFO persistence in one view.
+func getPersistenceState(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	raw, err := cachedReply(c, id, "INFO persistence", func() (string, error) {
+		return client.Info(c, "persistence").Result()
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read persistence state: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"persistence": parsePersistenceState(raw)})
+}
+
+// rewriteAOF starts an AOF rewrite with BGREWRITEAOF. While a BGSAVE runs the
+// server only schedules the rewrite; when one is already running nothing is
+// started and alreadyInProgress is true. Requires admin mode.
+func rewriteAOF(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	result, err := client.BgRewriteAOF(c).Result()
+	invalidateInfoReplies(id)
+	if err != nil {
+		if strings.Contains(err.Error(), "already in progress") {
+			c.JSON(http.StatusOK, gin.H{
+				"started":           false,
+				"scheduled":         false,
+				"alreadyInProgress": true,
+			})
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("BGREWRITEAOF failed: %v", err))
+		return
+	}
+
+	scheduled := strings.Contains(result, "scheduled")
+	c.JSON(http.StatusOK, gin.H{
+		"started":           !scheduled,
+		"scheduled":         scheduled,
+		"alreadyInProgress": false,
+		"result":            result,
+	})
+}
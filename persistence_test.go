@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSyncSaveOutlastsCommandTimeout(t *testing.T) {
+	previous := adminMode
+	adminMode = true
+	defer func() { adminMode = previous }()
+
+	const commandTimeout = 50 * time.Millisecond
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] == "SAVE" {
+			time.Sleep(3 * commandTimeout)
+		}
+		return "+OK\r\n"
+	})
+	const id = "test-save"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr, ReadTimeout: commandTimeout}))
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestTimeout)
+	router.POST("/save/:id", saveSnapshot)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/save/"+id+"?sync=true", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("SAVE slower than the command timeout returned %d: %s", w.Code, w.Body.String())
+	}
+}
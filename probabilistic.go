@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// probabilisticTypes maps the TYPE names of RedisBloom keys to the kind
+// reported by webredis and the command prefix of that kind.
+var probabilisticTypes = map[string]struct {
+	kind   string
+	prefix string
+}{
+	"MBbloom--": {"bloom", "BF"},
+	"MBbloomCF": {"cuckoo", "CF"},
+	"CMSk-TYPE": {"cms", "CMS"},
+}
+
+// requireBloomModule aborts with 501 when the server has not loaded
+// RedisBloom. If the module list cannot be read the command is simply tried.
+func requireBloomModule(c *gin.Context, id string) bool {
+	server, _ := getConnection(id)
+	info, err := serverInfoFor(c, id, server)
+	if err == nil && !info.hasModule("bf") {
+		respondError(c, http.StatusNotImplemented, errNotSupported, "Bloom filters, Cuckoo filters and Count-Min sketches require the RedisBloom module")
+		return false
+	}
+	return true
+}
+
+// probabilisticKey checks that key exists and is one of the wanted kinds,
+// writing the error response otherwise, and returns its kind and command
+// prefix.
+func probabilisticKey(c *gin.Context, client *redis.Client, key string, kinds ...string) (kind, prefix string, ok bool) {
+	keyType, err := client.Type(c, key).Result()
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return "", "", false
+	}
+	if keyType == "none" {
+		respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+		return "", "", false
+	}
+	probabilistic, known := probabilisticTypes[keyType]
+	if !known {
+		respondError(c, http.StatusBadRequest, errWrongType, fmt.Sprintf("key is of type %s, expected %s", keyType, strings.Join(kinds, " or ")))
+		return "", "", false
+	}
+	for _, k := range kinds {
+		if k == probabilistic.kind {
+			return probabilistic.kind, probabilistic.prefix, true
+		}
+	}
+	respondError(c, http.StatusBadRequest, errWrongType, fmt.Sprintf("key is a %s, expected %s", probabilistic.kind, strings.Join(kinds, " or ")))
+	return "", "", false
+}
+
+// respondBloomError turns the "unknown command" of a server without
+// RedisBloom into a 501 and reports anything else like other Redis errors.
+func respondBloomError(c *gin.Context, err error) {
+	if isUnsupportedCommand(err) {
+		respondError(c, http.StatusNotImplemented, errNotSupported, "Bloom filters, Cuckoo filters and Count-Min sketches require the RedisBloom module")
+		return
+	}
+	respondRedisError(c, err, err.Error())
+}
+
+// readProbabilisticInfo runs BF.INFO, CF.INFO or CMS.INFO on key and returns
+// its reply as a map, e.g. {"Capacity": 100, "Number of items inserted": 3}.
+func readProbabilisticInfo(c *gin.Context, client *redis.Client, key, prefix string) (map[string]interface{}, error) {
+	reply, err := client.Do(c, prefix+".INFO", key).Result()
+	if err != nil {
+		return nil, err
+	}
+	return replyMap(reply), nil
+}
+
+// getProbabilisticInfo describes a Bloom filter, Cuckoo filter or Count-Min
+// sketch with the INFO command of its kind.
+func getProbabilisticInfo(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok || !requireBloomModule(c, id) {
+		return
+	}
+
+	kind, prefix, ok := probabilisticKey(c, client, key, "bloom", "cuckoo", "cms")
+	if !ok {
+		return
+	}
+	info, err := readProbabilisticInfo(c, client, key, prefix)
+	if err != nil {
+		respondBloomError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kind": kind, "info": info})
+}
+
+// checkFilterItems reports with BF.MEXISTS or CF.MEXISTS whether each
+// ?item= may be in a Bloom or Cuckoo filter. true means "probably", false
+// means "definitely not".
+func checkFilterItems(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok || !requireBloomModule(c, id) {
+		return
+	}
+
+	items := c.QueryArray("item")
+	if len(items) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "item is required")
+		return
+	}
+	_, prefix, ok := probabilisticKey(c, client, key, "bloom", "cuckoo")
+	if !ok {
+		return
+	}
+
+	args := []interface{}{prefix + ".MEXISTS", key}
+	for _, item := range items {
+		args = append(args, item)
+	}
+	reply, err := client.Do(c, args...).Slice()
+	if err != nil {
+		respondBloomError(c, err)
+		return
+	}
+	exists := make(map[string]bool, len(items))
+	for i, item := range items {
+		exists[item] = i < len(reply) && replyFlag(reply[i])
+	}
+	c.JSON(http.StatusOK, gin.H{"exists": exists})
+}
+
+// queryCountMinSketch returns the estimated count of each ?item= in a
+// Count-Min sketch with CMS.QUERY. Estimates never undercount.
+func queryCountMinSketch(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok || !requireBloomModule(c, id) {
+		return
+	}
+
+	items := c.QueryArray("item")
+	if len(items) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "item is required")
+		return
+	}
+	if _, _, ok := probabilisticKey(c, client, key, "cms"); !ok {
+		return
+	}
+
+	args := []interface{}{"CMS.QUERY", key}
+	for _, item := range items {
+		args = append(args, item)
+	}
+	reply, err := client.Do(c, args...).Int64Slice()
+	if err != nil {
+		respondBloomError(c, err)
+		return
+	}
+	counts := make(map[string]int64, len(items))
+	for i, item := range items {
+		if i < len(reply) {
+			counts[item] = reply[i]
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"counts": counts})
+}
+
+// addFilterItems adds { "items": [...] } to a Bloom or Cuckoo filter with
+// BF.MADD or CF.ADD and reports per item whether it was new. Like module
+// writes from the console this requires admin mode.
+func addFilterItems(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok || !requireBloomModule(c, id) {
+		return
+	}
+
+	var data struct {
+		Items []string `json:"items"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Items) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "items is required")
+		return
+	}
+	kind, _, ok := probabilisticKey(c, client, key, "bloom", "cuckoo")
+	if !ok {
+		return
+	}
+
+	added := make(map[string]bool, len(data.Items))
+	if kind == "bloom" {
+		args := []interface{}{"BF.MADD", key}
+		for _, item := range data.Items {
+			args = append(args, item)
+		}
+		reply, err := client.Do(c, args...).Slice()
+		if err != nil {
+			respondBloomError(c, err)
+			return
+		}
+		for i, item := range data.Items {
+			added[item] = i < len(reply) && replyFlag(reply[i])
+		}
+	} else {
+		// CF.ADD takes a single item
+		cmds, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for _, item := range data.Items {
+				pipe.Do(c, "CF.ADD", key, item)
+			}
+			return nil
+		})
+		if err != nil {
+			respondBloomError(c, err)
+			return
+		}
+		for i, item := range data.Items {
+			added[item] = replyFlag(cmds[i].(*redis.Cmd).Val())
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"added": added})
+}
+
+// replyFlag reads a 0/1 reply, which RESP3 servers may send as a boolean.
+func replyFlag(reply interface{}) bool {
+	switch v := reply.(type) {
+	case bool:
+		return v
+	case int64:
+		return v == 1
+	}
+	return false
+}
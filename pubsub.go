@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type pubsubChannel struct {
+	Name        string `json:"name"`
+	Subscribers int64  `json:"subscribers"`
+}
+
+// getPubSubInfo combines PUBSUB CHANNELS, NUMSUB and NUMPAT. Channels come
+// from ?channels=a,b when given, otherwise from the active channels matching
+// ?pattern.
+func getPubSubInfo(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var names []string
+	if list := c.Query("channels"); list != "" {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	} else {
+		var err error
+		names, err = client.PubSubChannels(c, c.Query("pattern")).Result()
+		if err != nil {
+			respondRedisError(c, err, fmt.Sprintf("Failed to list channels: %v", err))
+			return
+		}
+	}
+
+	var (
+		numSub *redis.MapStringIntCmd
+		numPat *redis.IntCmd
+	)
+	_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		if len(names) > 0 {
+			numSub = pipe.PubSubNumSub(c, names...)
+		}
+		numPat = pipe.PubSubNumPat(c)
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read pub/sub state: %v", err))
+		return
+	}
+
+	channels := make([]pubsubChannel, len(names))
+	for i, name := range names {
+		channels[i] = pubsubChannel{Name: name}
+		if numSub != nil {
+			channels[i].Subscribers = numSub.Val()[name]
+		}
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].Name < channels[j].Name })
+
+	c.JSON(http.StatusOK, gin.H{
+		"channels":           channels,
+		"patternSubscribers": numPat.Val(),
+	})
+}
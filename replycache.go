@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// replyCacheTTL is how long introspection replies such as INFO sections,
+// CONFIG GET, COMMAND DOCS and CLUSTER SLOTS are reused for a connection, so
+// polling dashboards do not hit the server each time. 0 disables the cache.
+var replyCacheTTL = envDuration("WEBREDIS_REPLY_CACHE_TTL", 5*time.Second)
+
+type cachedReplyEntry struct {
+	reply     interface{}
+	fetchedAt time.Time
+}
+
+// connectionReplies are the cached replies of one connection by command, e.g.
+// "CONFIG GET maxmemory-policy". generation is bumped on every invalidation,
+// so a reply fetched while it happened is not stored afterwards.
+type connectionReplies struct {
+	entries    map[string]cachedReplyEntry
+	generation int
+}
+
+var (
+	replyCache   = make(map[string]*connectionReplies)
+	replyCacheMu sync.Mutex
+)
+
+// cachedReply returns the cached reply of command on connection id, calling
+// fetch when there is none younger than replyCacheTTL or the request asks
+// for ?fresh=true. Errors are never cached. Replies are shared between
+// requests and must not be modified.
+func cachedReply[T any](c *gin.Context, id, command string, fetch func() (T, error)) (T, error) {
+	if replyCacheTTL <= 0 {
+		return fetch()
+	}
+
+	replyCacheMu.Lock()
+	replies := replyCache[id]
+	if replies == nil {
+		replies = &connectionReplies{entries: make(map[string]cachedReplyEntry)}
+		replyCache[id] = replies
+	}
+	generation := replies.generation
+	entry, ok := replies.entries[command]
+	replyCacheMu.Unlock()
+	if ok && c.Query("fresh") != "true" && time.Since(entry.fetchedAt) < replyCacheTTL {
+		if reply, ok := entry.reply.(T); ok {
+			return reply, nil
+		}
+	}
+
+	reply, err := fetch()
+	if err != nil {
+		return reply, err
+	}
+
+	replyCacheMu.Lock()
+	defer replyCacheMu.Unlock()
+	// The connection may have been forgotten or invalidated meanwhile
+	if replyCache[id] != replies || replies.generation != generation {
+		return reply, nil
+	}
+	// Drop expired entries, e.g. COMMAND DOCS of names asked for once
+	now := time.Now()
+	for name, old := range replies.entries {
+		if now.Sub(old.fetchedAt) >= replyCacheTTL {
+			delete(replies.entries, name)
+		}
+	}
+	replies.entries[command] = cachedReplyEntry{reply: reply, fetchedAt: now}
+	return reply, nil
+}
+
+// invalidateReplies drops the cached replies of connection id for which
+// match returns true.
+func invalidateReplies(id string, match func(command string) bool) {
+	replyCacheMu.Lock()
+	defer replyCacheMu.Unlock()
+	replies := replyCache[id]
+	if replies == nil {
+		return
+	}
+	replies.generation++
+	for command := range replies.entries {
+		if match(command) {
+			delete(replies.entries, command)
+		}
+	}
+}
+
+// invalidateConfigReplies drops the cached CONFIG GET replies whose pattern
+// covers param, after a CONFIG SET of it. INFO shows several settings too,
+// e.g. maxmemory, so its sections go as well.
+func invalidateConfigReplies(id, param string) {
+	param = strings.ToLower(param)
+	invalidateReplies(id, func(command string) bool {
+		if pattern, ok := strings.CutPrefix(command, "CONFIG GET "); ok {
+			return globMatch(strings.ToLower(pattern), param)
+		}
+		return strings.HasPrefix(command, "INFO ")
+	})
+}
+
+// invalidateInfoReplies drops the cached INFO sections, e.g. after CONFIG
+// RESETSTAT.
+func invalidateInfoReplies(id string) {
+	invalidateReplies(id, func(command string) bool {
+		return strings.HasPrefix(command, "INFO ")
+	})
+}
+
+// invalidateRepliesAfter drops the cached replies a console command may have
+// made stale.
+func invalidateRepliesAfter(id, command string, args []string) {
+	switch strings.ToUpper(command) {
+	case "CONFIG":
+		if len(args) == 0 {
+			return
+		}
+		switch strings.ToUpper(args[0]) {
+		case "SET":
+			// CONFIG SET name value [name value ...]
+			for i := 1; i < len(args); i += 2 {
+				invalidateConfigReplies(id, args[i])
+			}
+		case "RESETSTAT":
+			invalidateInfoReplies(id)
+		}
+	case "SAVE", "BGSAVE", "BGREWRITEAOF":
+		invalidateInfoReplies(id)
+	case "CLUSTER", "MODULE", "FAILOVER", "REPLICAOF", "SLAVEOF":
+		forgetReplyCache(id)
+	}
+}
+
+// forgetReplyCache drops every cached reply of a connection.
+func forgetReplyCache(id string) {
+	replyCacheMu.Lock()
+	delete(replyCache, id)
+	replyCacheMu.Unlock()
+}
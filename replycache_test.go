@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newReplyCacheContext(target string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	return c
+}
+
+// useReplyCacheTTL sets replyCacheTTL for one test.
+func useReplyCacheTTL(t *testing.T, ttl time.Duration) {
+	previous := replyCacheTTL
+	replyCacheTTL = ttl
+	t.Cleanup(func() { replyCacheTTL = previous })
+}
+
+func TestCachedReplyExpires(t *testing.T) {
+	useReplyCacheTTL(t, 50*time.Millisecond)
+	const id = "test-replycache-ttl"
+	defer forgetReplyCache(id)
+
+	fetches := 0
+	fetch := func() (int, error) {
+		fetches++
+		return fetches, nil
+	}
+	c := newReplyCacheContext("/")
+	if reply, _ := cachedReply(c, id, "INFO server", fetch); reply != 1 {
+		t.Fatalf("first reply = %d, want 1", reply)
+	}
+	if reply, _ := cachedReply(c, id, "INFO server", fetch); reply != 1 {
+		t.Errorf("reply within the TTL = %d, want the cached 1", reply)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if reply, _ := cachedReply(c, id, "INFO server", fetch); reply != 2 {
+		t.Errorf("reply after the TTL = %d, want a fresh 2", reply)
+	}
+}
+
+func TestCachedReplyFreshBypass(t *testing.T) {
+	useReplyCacheTTL(t, time.Minute)
+	const id = "test-replycache-fresh"
+	defer forgetReplyCache(id)
+
+	fetches := 0
+	fetch := func() (int, error) {
+		fetches++
+		return fetches, nil
+	}
+	cachedReply(newReplyCacheContext("/"), id, "CONFIG GET maxmemory", fetch)
+	if reply, _ := cachedReply(newReplyCacheContext("/?fresh=true"), id, "CONFIG GET maxmemory", fetch); reply != 2 {
+		t.Errorf("reply with ?fresh=true = %d, want a fresh 2", reply)
+	}
+	// The fresh reply replaces the cached one
+	if reply, _ := cachedReply(newReplyCacheContext("/"), id, "CONFIG GET maxmemory", fetch); reply != 2 {
+		t.Errorf("reply after ?fresh=true = %d, want the cached 2", reply)
+	}
+}
+
+func TestCachedReplyGenerationGuard(t *testing.T) {
+	useReplyCacheTTL(t, time.Minute)
+	const id = "test-replycache-generation"
+	defer forgetReplyCache(id)
+
+	c := newReplyCacheContext("/")
+	// CONFIG SET runs while the CONFIG GET reply is being fetched, so the
+	// reply may predate it and must not be cached
+	cachedReply(c, id, "CONFIG GET maxmemory", func() (string, error) {
+		invalidateConfigReplies(id, "maxmemory")
+		return "stale", nil
+	})
+	reply, _ := cachedReply(c, id, "CONFIG GET maxmemory", func() (string, error) {
+		return "current", nil
+	})
+	if reply != "current" {
+		t.Errorf("reply = %q, want the one fetched after the invalidation", reply)
+	}
+}
+
+func TestInvalidateConfigReplies(t *testing.T) {
+	useReplyCacheTTL(t, time.Minute)
+	const id = "test-replycache-invalidate"
+	defer forgetReplyCache(id)
+
+	c := newReplyCacheContext("/")
+	for _, command := range []string{"CONFIG GET maxmemory*", "CONFIG GET timeout", "INFO memory"} {
+		cachedReply(c, id, command, func() (string, error) { return "old", nil })
+	}
+	invalidateConfigReplies(id, "maxmemory-policy")
+
+	for command, want := range map[string]string{
+		"CONFIG GET maxmemory*": "new",
+		"CONFIG GET timeout":    "old",
+		"INFO memory":           "new",
+	} {
+		reply, _ := cachedReply(c, id, command, func() (string, error) { return "new", nil })
+		if reply != want {
+			t.Errorf("%s after CONFIG SET maxmemory-policy = %q, want %q", command, reply, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sampleMaxCount caps how many members one sample may return.
+const sampleMaxCount = 1000
+
+// sampleKey returns random members of a set, hash or sorted set with
+// SRANDMEMBER, HRANDFIELD ... WITHVALUES or ZRANDMEMBER ... WITHSCORES,
+// without paging through the whole key. A positive ?count returns distinct
+// members (fewer if the key is smaller), a negative one exactly |count|
+// members that may repeat.
+func sampleKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "10"))
+	if err != nil || count == 0 || count > sampleMaxCount || count < -sampleMaxCount {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("count must be a non-zero number between -%d and %d", sampleMaxCount, sampleMaxCount))
+		return
+	}
+
+	keyType, err := client.Type(c, key).Result()
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	var sample interface{}
+	switch keyType {
+	case "none":
+		respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+		return
+	case "set":
+		var members []string
+		members, err = client.SRandMemberN(c, key, int64(count)).Result()
+		sample = decodeValues(members)
+	case "hash":
+		fields, cmdErr := client.HRandFieldWithValues(c, key, count).Result()
+		entries := make([]gin.H, len(fields))
+		for i, kv := range fields {
+			entries[i] = gin.H{"field": kv.Key, "value": decodeValue(kv.Value)}
+		}
+		sample, err = entries, cmdErr
+	case "zset":
+		members, cmdErr := client.ZRandMemberWithScores(c, key, count).Result()
+		sample, err = decodeZSlice(members), cmdErr
+	default:
+		respondError(c, http.StatusBadRequest, errUnsupportedType, fmt.Sprintf("Cannot sample keys of type %s", keyType))
+		return
+	}
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "HRANDFIELD and ZRANDMEMBER require Redis 6.2 or newer")
+			return
+		}
+		if isWrongType(err) {
+			respondWrongType(c, client, key, keyType)
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to sample key: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":   keyType,
+		"count":  count,
+		"sample": sample,
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefixes holds the KeyPrefix of every connection scoped to a part of
+// the keyspace. Connections without a prefix have no entry.
+var (
+	keyPrefixes   = make(map[string]string)
+	keyPrefixesMu sync.RWMutex
+)
+
+// setKeyPrefix configures the key prefix of connection id; an empty prefix
+// removes the scope.
+func setKeyPrefix(id, prefix string) {
+	keyPrefixesMu.Lock()
+	defer keyPrefixesMu.Unlock()
+
+	if prefix == "" {
+		delete(keyPrefixes, id)
+		return
+	}
+	keyPrefixes[id] = prefix
+}
+
+// forgetKeyPrefix drops the prefix of a deleted connection.
+func forgetKeyPrefix(id string) {
+	setKeyPrefix(id, "")
+}
+
+func keyPrefixFor(id string) string {
+	keyPrefixesMu.RLock()
+	defer keyPrefixesMu.RUnlock()
+	return keyPrefixes[id]
+}
+
+// scopePattern restricts a SCAN MATCH pattern to the key prefix of
+// connection id. Patterns already starting with the prefix are kept, others
+// are placed under it: with prefix "teamA:", "*" becomes "teamA:*" and
+// "user:*" becomes "teamA:user:*". Glob characters in the prefix are escaped
+// so they match literally.
+func scopePattern(id, pattern string) string {
+	prefix := keyPrefixFor(id)
+	if prefix == "" {
+		return pattern
+	}
+	escaped := escapeGlob(prefix)
+	if strings.HasPrefix(pattern, escaped) {
+		return pattern
+	}
+	if pattern == "" {
+		pattern = "*"
+	}
+	return escaped + pattern
+}
+
+// keyInScope reports whether key lies under the key prefix of connection id.
+func keyInScope(id, key string) bool {
+	return strings.HasPrefix(key, keyPrefixFor(id))
+}
+
+// requireKeysInScope responds 403 and returns false if any key is outside
+// the key prefix of connection id.
+func requireKeysInScope(c *gin.Context, id string, keys ...string) bool {
+	for _, key := range keys {
+		if !keyInScope(id, key) {
+			respondError(c, http.StatusForbidden, errKeyOutOfScope, fmt.Sprintf("Key '%s' is outside the prefix '%s' of this connection", key, keyPrefixFor(id)))
+			return false
+		}
+	}
+	return true
+}
+
+// requireCommandInScope resolves the keys of a console command with COMMAND
+// GETKEYS and responds 403 if any is outside the key prefix of connection
+// id. Commands without key arguments pass; if the keys cannot be resolved
+// the command is refused, so the check fails closed.
+//
+// Some commands read keys GETKEYS does not report. The patterns of KEYS and
+// SCAN are rewritten with scopePattern, so the command to run is returned,
+// RANDOMKEY is refused, and the BY and GET patterns of SORT must lie under
+// the prefix.
+func requireCommandInScope(c *gin.Context, client *redis.Client, id string, args []interface{}) ([]interface{}, bool) {
+	if keyPrefixFor(id) == "" {
+		return args, true
+	}
+	switch strings.ToUpper(fmt.Sprint(args[0])) {
+	case "KEYS":
+		if len(args) == 2 {
+			args[1] = scopePattern(id, fmt.Sprint(args[1]))
+		}
+	case "SCAN":
+		args = scopeScanArgs(id, args)
+	case "RANDOMKEY":
+		respondError(c, http.StatusForbidden, errKeyOutOfScope, fmt.Sprintf("RANDOMKEY may return a key outside the prefix '%s' of this connection", keyPrefixFor(id)))
+		return nil, false
+	case "SORT", "SORT_RO":
+		if !requireKeysInScope(c, id, sortPatterns(args)...) {
+			return nil, false
+		}
+	}
+	keys, err := client.CommandGetKeys(c, args...).Result()
+	if err != nil {
+		if !commandHasKeys(c, client, fmt.Sprint(args[0])) {
+			return args, true
+		}
+		if isRedisServerError(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Cannot resolve the keys of the command: %v", err))
+			return nil, false
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to resolve the keys of the command: %v", err))
+		return nil, false
+	}
+	return args, requireKeysInScope(c, id, keys...)
+}
+
+// scopeScanArgs rewrites the MATCH pattern of SCAN cursor [MATCH pattern]
+// [COUNT count] [TYPE type] with scopePattern, adding one if none is given.
+// Every option takes one value, so options and values alternate.
+func scopeScanArgs(id string, args []interface{}) []interface{} {
+	for i := 2; i+1 < len(args); i += 2 {
+		if strings.EqualFold(fmt.Sprint(args[i]), "MATCH") {
+			args[i+1] = scopePattern(id, fmt.Sprint(args[i+1]))
+			return args
+		}
+	}
+	return append(args, "MATCH", scopePattern(id, "*"))
+}
+
+// sortPatterns returns the BY and GET patterns of SORT key [BY pattern]
+// [LIMIT offset count] [GET pattern ...] [ASC|DESC] [ALPHA] [STORE dest].
+// "nosort" and "#" name no key and are left out.
+func sortPatterns(args []interface{}) []string {
+	var patterns []string
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(fmt.Sprint(args[i])) {
+		case "BY", "GET":
+			if i+1 < len(args) {
+				pattern := fmt.Sprint(args[i+1])
+				if pattern != "#" && !strings.EqualFold(pattern, "nosort") {
+					patterns = append(patterns, pattern)
+				}
+			}
+			i++
+		case "LIMIT":
+			i += 2
+		case "STORE":
+			i++
+		}
+	}
+	return patterns
+}
+
+// commandHasKeys reports whether COMMAND INFO lists key arguments for a
+// command: a first key position, or the movablekeys flag. Failed lookups
+// count as having keys.
+func commandHasKeys(ctx context.Context, client *redis.Client, command string) bool {
+	reply, err := client.Do(ctx, "COMMAND", "INFO", strings.ToLower(command)).Slice()
+	if err != nil || len(reply) == 0 {
+		return true
+	}
+	info, ok := reply[0].([]interface{})
+	if !ok || len(info) < 4 {
+		return true
+	}
+	for _, flag := range replyStrings(info[2]) {
+		if strings.EqualFold(flag, "movablekeys") {
+			return true
+		}
+	}
+	firstKey, _ := info[3].(int64)
+	return firstKey != 0
+}
+
+// countKeysInScope returns how many keys of a database connection id sees:
+// DBSIZE without a key prefix, otherwise the distinct keys SCAN finds under
+// the prefix, which reads the keyspace once.
+func countKeysInScope(ctx context.Context, client *redis.Client, id string) (int64, error) {
+	if keyPrefixFor(id) == "" {
+		return client.DBSize(ctx).Result()
+	}
+	pattern := scopePattern(id, "*")
+	seen := make(map[string]bool)
+	var cursor uint64
+	for {
+		keys, next, err := client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to scan keys: %w", err)
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+		if next == 0 {
+			return int64(len(seen)), nil
+		}
+		cursor = next
+	}
+}
+
+// escapeGlob escapes the characters SCAN MATCH treats as wildcards.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
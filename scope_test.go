@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRequireCommandInScope(t *testing.T) {
+	server := newFakeRedis(t, func(args []string) string {
+		if !strings.EqualFold(args[0], "COMMAND") {
+			return "+OK\r\n"
+		}
+		if strings.EqualFold(args[1], "GETKEYS") {
+			if !strings.EqualFold(args[2], "sort") {
+				return "-ERR The command has no key arguments\r\n"
+			}
+			return fmt.Sprintf("*1\r\n$%d\r\n%s\r\n", len(args[3]), args[3])
+		}
+		// COMMAND INFO of a keyless command: no first key, no movablekeys
+		return "*1\r\n*4\r\n$4\r\nkeys\r\n:2\r\n*0\r\n:0\r\n"
+	})
+	const id = "test-scope"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+	setKeyPrefix(id, "teamA:")
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+		forgetKeyPrefix(id)
+	}()
+	client, _ := getConnection(id)
+
+	tests := []struct {
+		name string
+		args []interface{}
+		want []interface{} // nil when the command is refused
+	}{
+		{"keys", []interface{}{"KEYS", "*"}, []interface{}{"KEYS", "teamA:*"}},
+		{"keys under the prefix", []interface{}{"keys", "teamA:user:*"}, []interface{}{"keys", "teamA:user:*"}},
+		{"scan", []interface{}{"SCAN", "0"}, []interface{}{"SCAN", "0", "MATCH", "teamA:*"}},
+		{"scan match", []interface{}{"scan", "0", "COUNT", "10", "match", "user:*"}, []interface{}{"scan", "0", "COUNT", "10", "match", "teamA:user:*"}},
+		{"randomkey", []interface{}{"RANDOMKEY"}, nil},
+		{"sort by outside", []interface{}{"SORT", "teamA:ids", "BY", "weight_*"}, nil},
+		{"sort get outside", []interface{}{"SORT", "teamA:ids", "LIMIT", "0", "5", "GET", "#", "GET", "teamB:*"}, nil},
+		{"sort in scope", []interface{}{"SORT", "teamA:ids", "BY", "nosort", "GET", "#", "GET", "teamA:*->name"}, []interface{}{"SORT", "teamA:ids", "BY", "nosort", "GET", "#", "GET", "teamA:*->name"}},
+		{"sort outside", []interface{}{"SORT", "teamB:ids"}, nil},
+	}
+	gin.SetMode(gin.TestMode)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("POST", "/", nil)
+		got, ok := requireCommandInScope(c, client, id, tt.args)
+		switch {
+		case tt.want == nil && (ok || w.Code != http.StatusForbidden):
+			t.Errorf("%s: allowed %v, want 403", tt.name, got)
+		case tt.want != nil && (!ok || !reflect.DeepEqual(got, tt.want)):
+			t.Errorf("%s: got %v, %v (%s), want %v", tt.name, got, ok, w.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestScopedCountsLeaveOutOtherKeys(t *testing.T) {
+	server := newFakeRedis(t, func(args []string) string {
+		switch strings.ToLower(args[0]) {
+		case "dbsize":
+			return ":100\r\n"
+		case "scan":
+			// SCAN may return a key twice
+			return "*2\r\n$1\r\n0\r\n*3\r\n$7\r\nteamA:a\r\n$7\r\nteamA:b\r\n$7\r\nteamA:a\r\n"
+		case "memory":
+			return ":50\r\n"
+		}
+		return "+OK\r\n"
+	})
+	const id = "test-scoped-counts"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+	setKeyPrefix(id, "teamA:")
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+		forgetKeyPrefix(id)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/keys/:id/:db/count", getKeyCount)
+	router.GET("/keys/:id/:db/memory-estimate", estimateMemory)
+	get := func(path string, result interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/keys/"+id+"/0/"+path, nil))
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatalf("%d %s: %v", w.Code, w.Body.String(), err)
+		}
+	}
+
+	var count keyCountResponse
+	get("count", &count)
+	if count.Count != 2 {
+		t.Errorf("key count = %d, want the 2 keys under the prefix", count.Count)
+	}
+	var estimate struct {
+		DBSize         int64 `json:"dbSize"`
+		EstimatedBytes int64 `json:"estimatedBytes"`
+	}
+	get("memory-estimate", &estimate)
+	if estimate.DBSize != 2 || estimate.EstimatedBytes != 100 {
+		t.Errorf("estimate = %+v, want 2 keys of 50 bytes", estimate)
+	}
+	if calls := server.received("dbsize"); len(calls) != 0 {
+		t.Errorf("DBSIZE read on a scoped connection: %v", calls)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// processStart is when webredis started, for the uptime in /self/stats.
+var processStart = time.Now()
+
+type poolStats struct {
+	Hits       uint32 `json:"hits"`     // a free connection was found in the pool
+	Misses     uint32 `json:"misses"`   // a new connection had to be dialed
+	Timeouts   uint32 `json:"timeouts"` // waiting for a free connection timed out
+	TotalConns uint32 `json:"totalConns"`
+	IdleConns  uint32 `json:"idleConns"`
+	StaleConns uint32 `json:"staleConns"` // closed for being idle or too old
+}
+
+type connectionPoolStats struct {
+	ID   string    `json:"id"`
+	Pool poolStats `json:"pool"`
+	// Pools of the per-database clients by database number
+	Databases map[string]poolStats `json:"databases"`
+}
+
+func newPoolStats(client *redis.Client) poolStats {
+	stats := client.PoolStats()
+	return poolStats{
+		Hits:       stats.Hits,
+		Misses:     stats.Misses,
+		Timeouts:   stats.Timeouts,
+		TotalConns: stats.TotalConns,
+		IdleConns:  stats.IdleConns,
+		StaleConns: stats.StaleConns,
+	}
+}
+
+// getSelfStats reports the resource usage of the webredis process and the
+// pool stats of every connection, to spot pool exhaustion (timeouts,
+// totalConns at the pool size) and leaks (a growing goroutine count or heap).
+func getSelfStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	ids := connectionIDs()
+	sort.Strings(ids)
+	pools := make([]connectionPoolStats, 0, len(ids))
+	byID := make(map[string]*connectionPoolStats)
+	for _, id := range ids {
+		client, exists := getConnection(id)
+		if !exists {
+			continue
+		}
+		pools = append(pools, connectionPoolStats{ID: id, Pool: newPoolStats(client), Databases: make(map[string]poolStats)})
+	}
+	for i := range pools {
+		byID[pools[i].ID] = &pools[i]
+	}
+
+	dbClientsMu.Lock()
+	for cacheKey, client := range dbClients {
+		id, db, _ := strings.Cut(cacheKey, "/")
+		if pool, ok := byID[id]; ok {
+			pool.Databases[db] = newPoolStats(client)
+		}
+	}
+	dbClientsMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"uptimeSeconds": int64(time.Since(processStart).Seconds()),
+		"goroutines":    runtime.NumGoroutine(),
+		"memory": gin.H{
+			"allocBytes":      mem.Alloc,
+			"totalAllocBytes": mem.TotalAlloc,
+			"sysBytes":        mem.Sys,
+			"heapAllocBytes":  mem.HeapAlloc,
+			"heapInuseBytes":  mem.HeapInuse,
+			"heapIdleBytes":   mem.HeapIdle,
+			"heapObjects":     mem.HeapObjects,
+			"stackInuseBytes": mem.StackInuse,
+			"numGC":           mem.NumGC,
+			"pauseTotalNs":    mem.PauseTotalNs,
+		},
+		"connectionCount": len(pools),
+		"connections":     pools,
+	})
+}
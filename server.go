@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// serverInfoCacheTTL is how long the version and module list of a server are
+// reused. They only change on upgrade or MODULE LOAD.
+const serverInfoCacheTTL = 10 * time.Minute
+
+type serverModule struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+// serverCapabilities lists the features webredis can use on a server, derived
+// from its version.
+type serverCapabilities struct {
+	SupportsScanType       bool `json:"supportsScanType"`       // 6.0
+	SupportsResp3          bool `json:"supportsResp3"`          // 6.0
+	SupportsClientTracking bool `json:"supportsClientTracking"` // 6.0
+	SupportsACL            bool `json:"supportsAcl"`            // 6.0
+	SupportsLpos           bool `json:"supportsLpos"`           // 6.0.6
+	SupportsCopy           bool `json:"supportsCopy"`           // 6.2
+	SupportsGetdel         bool `json:"supportsGetdel"`         // 6.2
+	SupportsGetex          bool `json:"supportsGetex"`          // 6.2
+	SupportsCommandDocs    bool `json:"supportsCommandDocs"`    // 7.0
+	SupportsFunctions      bool `json:"supportsFunctions"`      // 7.0
+	SupportsExpireOptions  bool `json:"supportsExpireOptions"`  // 7.0, EXPIRE NX/XX/GT/LT
+	SupportsReadOnlyEval   bool `json:"supportsReadOnlyEval"`   // 7.0, EVAL_RO/EVALSHA_RO
+	SupportsWaitAOF        bool `json:"supportsWaitAof"`        // 7.2
+	SupportsHashFieldTTL   bool `json:"supportsHashFieldTtl"`   // 7.4
+	SupportsHscanNovalues  bool `json:"supportsHscanNovalues"`  // 7.4
+}
+
+type serverInfo struct {
+	Version      string             `json:"version"`
+	Mode         string             `json:"mode"`
+	OS           string             `json:"os"`
+	Modules      []serverModule     `json:"modules"`
+	Capabilities serverCapabilities `json:"capabilities"`
+	fetchedAt    time.Time
+}
+
+var (
+	serverInfoCache   = make(map[string]serverInfo)
+	serverInfoCacheMu sync.Mutex
+)
+
+// getServerInfo returns the server version, modules and capabilities of a
+// connection. ?refresh=true bypasses the cache.
+func getServerInfo(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	if c.Query("refresh") == "true" {
+		forgetServerInfo(id)
+	}
+	info, err := serverInfoFor(c, id, client)
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read server info: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// serverInfoFor returns the cached server info of connection id, reading it
+// from INFO when missing or stale.
+func serverInfoFor(ctx context.Context, id string, client *redis.Client) (serverInfo, error) {
+	serverInfoCacheMu.Lock()
+	info, ok := serverInfoCache[id]
+	serverInfoCacheMu.Unlock()
+	if ok && time.Since(info.fetchedAt) < serverInfoCacheTTL {
+		return info, nil
+	}
+
+	raw, err := client.Info(ctx, "server", "modules").Result()
+	if err != nil {
+		return serverInfo{}, err
+	}
+	info = parseServerInfo(raw)
+
+	serverInfoCacheMu.Lock()
+	serverInfoCache[id] = info
+	serverInfoCacheMu.Unlock()
+	return info, nil
+}
+
+// detectServerMode reads redis_mode from INFO when a connection is added.
+// webredis talks to a single node, so for cluster nodes and Sentinels it also
+// returns a warning explaining what will not work and, for a Sentinel, which
+// masters to add instead. The mode is empty if INFO is not allowed.
+func detectServerMode(ctx context.Context, client *redis.Client) (mode, warning string) {
+	raw, err := client.Info(ctx, "server").Result()
+	if err != nil {
+		return "", ""
+	}
+	mode = parseServerInfo(raw).Mode
+	switch mode {
+	case "cluster":
+		warning = "This is a Redis Cluster node: only keys in its own hash slots can be read, others fail with MOVED. /api/cluster/:id/slots lists which node owns which slots"
+	case "sentinel":
+		warning = "This is a Sentinel, which holds no data; add the master it monitors instead"
+		masters, err := client.Do(ctx, "SENTINEL", "MASTERS").Slice()
+		if err != nil {
+			break
+		}
+		var addresses []string
+		for _, master := range masters {
+			fields := replyMap(master)
+			addresses = append(addresses, fmt.Sprintf("%v at %v:%v", fields["name"], fields["ip"], fields["port"]))
+		}
+		if len(addresses) > 0 {
+			warning += ": " + strings.Join(addresses, ", ")
+		}
+	case "":
+		// Servers before 2.6 have no redis_mode
+		mode = "standalone"
+	}
+	return mode, warning
+}
+
+// forgetServerInfo drops the cached server info of a connection.
+func forgetServerInfo(id string) {
+	serverInfoCacheMu.Lock()
+	delete(serverInfoCache, id)
+	serverInfoCacheMu.Unlock()
+}
+
+// parseServerInfo extracts the fields webredis cares about from an INFO
+// reply. Module lines look like
+// "module:name=search,ver=20612,api=1,filters=0,...".
+func parseServerInfo(raw string) serverInfo {
+	info := serverInfo{Modules: []serverModule{}, fetchedAt: time.Now()}
+	for _, line := range strings.Split(raw, "\n") {
+		name, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found {
+			continue
+		}
+		switch name {
+		case "redis_version":
+			info.Version = value
+		case "redis_mode":
+			info.Mode = value
+		case "os":
+			info.OS = value
+		case "module":
+			var module serverModule
+			for _, field := range strings.Split(value, ",") {
+				k, v, _ := strings.Cut(field, "=")
+				switch k {
+				case "name":
+					module.Name = v
+				case "ver":
+					module.Version, _ = strconv.Atoi(v)
+				}
+			}
+			info.Modules = append(info.Modules, module)
+		}
+	}
+
+	v := parseVersion(info.Version)
+	info.Capabilities = serverCapabilities{
+		SupportsScanType:       versionAtLeast(v, 6, 0, 0),
+		SupportsResp3:          versionAtLeast(v, 6, 0, 0),
+		SupportsClientTracking: versionAtLeast(v, 6, 0, 0),
+		SupportsACL:            versionAtLeast(v, 6, 0, 0),
+		SupportsLpos:           versionAtLeast(v, 6, 0, 6),
+		SupportsCopy:           versionAtLeast(v, 6, 2, 0),
+		SupportsGetdel:         versionAtLeast(v, 6, 2, 0),
+		SupportsGetex:          versionAtLeast(v, 6, 2, 0),
+		SupportsCommandDocs:    versionAtLeast(v, 7, 0, 0),
+		SupportsFunctions:      versionAtLeast(v, 7, 0, 0),
+		SupportsExpireOptions:  versionAtLeast(v, 7, 0, 0),
+		SupportsReadOnlyEval:   versionAtLeast(v, 7, 0, 0),
+		SupportsWaitAOF:        versionAtLeast(v, 7, 2, 0),
+		SupportsHashFieldTTL:   versionAtLeast(v, 7, 4, 0),
+		SupportsHscanNovalues:  versionAtLeast(v, 7, 4, 0),
+	}
+	return info
+}
+
+// hasModule reports whether the server has loaded a module with that name.
+func (info serverInfo) hasModule(name string) bool {
+	for _, module := range info.Modules {
+		if strings.EqualFold(module.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVersion turns "7.2.4" into [7 2 4]. Missing or malformed parts are 0.
+func parseVersion(version string) [3]int {
+	var v [3]int
+	for i, part := range strings.SplitN(version, ".", 3) {
+		v[i], _ = strconv.Atoi(part)
+	}
+	return v
+}
+
+func versionAtLeast(v [3]int, major, minor, patch int) bool {
+	if v[0] != major {
+		return v[0] > major
+	}
+	if v[1] != minor {
+		return v[1] > minor
+	}
+	return v[2] >= patch
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// toggleMemberScript adds the member when absent and removes it when present,
+// returning the new membership as 1 or 0. Running as a script keeps the check
+// and the write atomic.
+var toggleMemberScript = redis.NewScript(`
+local t = redis.call('TYPE', KEYS[1])['ok']
+if t ~= 'set' and t ~= 'none' then
+	return redis.error_reply('WRONGTYPE Operation against a key holding the wrong kind of value')
+end
+if redis.call('SISMEMBER', KEYS[1], ARGV[1]) == 1 then
+	redis.call('SREM', KEYS[1], ARGV[1])
+	return 0
+end
+redis.call('SADD', KEYS[1], ARGV[1])
+return 1
+`)
+
+func moveSetMember(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Source      string      `json:"source"`
+		Destination string      `json:"destination"`
+		Member      interface{} `json:"member"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Source == "" || data.Destination == "" || data.Member == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "source, destination and member are required")
+		return
+	}
+	if !requireKeysInScope(c, id, data.Source, data.Destination) {
+		return
+	}
+
+	member, err := stringValue(data.Member)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Failed to convert member to string")
+		return
+	}
+
+	// SMOVE reports WRONGTYPE without saying which key is wrong, so check
+	// both up front to name it in the error
+	var sourceType, destinationType *redis.StatusCmd
+	_, err = client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		sourceType = pipe.Type(c, data.Source)
+		destinationType = pipe.Type(c, data.Destination)
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read key types: %v", err))
+		return
+	}
+	for key, cmd := range map[string]*redis.StatusCmd{data.Source: sourceType, data.Destination: destinationType} {
+		if t := cmd.Val(); t != "set" && t != "none" {
+			respondError(c, http.StatusConflict, errWrongType, fmt.Sprintf("key '%s' is of type %s, expected set", key, t))
+			return
+		}
+	}
+
+	moved, err := client.SMove(c, data.Source, data.Destination, member).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondError(c, http.StatusConflict, errWrongType, "source and destination must both be sets")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to move member: %v", err))
+		return
+	}
+	if !moved {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Member is not in set '%s'", data.Source))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"member":        member,
+		"inSource":      data.Source == data.Destination,
+		"inDestination": true,
+	})
+}
+
+func toggleSetMember(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Member interface{} `json:"member"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Member == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "member is required")
+		return
+	}
+
+	member, err := stringValue(data.Member)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Failed to convert member to string")
+		return
+	}
+
+	isMember, err := toggleMemberScript.Run(c, client, []string{key}, member).Int()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "set")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to toggle member: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"member":   member,
+		"isMember": isMember == 1,
+	})
+}
+
+// countSetIntersection returns the size of the intersection of several sets
+// (SINTERCARD, Redis 7). A limit stops counting early once reached.
+func countSetIntersection(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Keys  []string `json:"keys"`
+		Limit int64    `json:"limit"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Keys) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "keys is required")
+		return
+	}
+	if !requireKeysInScope(c, id, data.Keys...) {
+		return
+	}
+	if data.Limit < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "limit must not be negative")
+		return
+	}
+
+	count, err := client.SInterCard(c, data.Limit, data.Keys...).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondError(c, http.StatusConflict, errWrongType, "all keys must be sets")
+			return
+		}
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "SINTERCARD requires Redis 7.0 or newer")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to count intersection: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func sortKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		By    string   `json:"by"`
+		Get   []string `json:"get"`
+		Limit []int64  `json:"limit"` // [offset, count]
+		Order string   `json:"order"`
+		Alpha bool     `json:"alpha"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	order := strings.ToUpper(data.Order)
+	if order != "" && order != "ASC" && order != "DESC" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "order must be ASC or DESC")
+		return
+	}
+	if len(data.Limit) != 0 && len(data.Limit) != 2 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "limit must be [offset, count]")
+		return
+	}
+	// Rows are keyed by pattern, so a repeated pattern would lose a column
+	seen := make(map[string]bool, len(data.Get))
+	for _, pattern := range data.Get {
+		if seen[pattern] {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("get pattern '%s' is given twice", pattern))
+			return
+		}
+		seen[pattern] = true
+	}
+
+	// BY and GET read the keys their patterns name, so those must stay
+	// under the key prefix too. "nosort" and "#" name no key.
+	var patterns []string
+	if data.By != "" && !strings.EqualFold(data.By, "nosort") {
+		patterns = append(patterns, data.By)
+	}
+	for _, pattern := range data.Get {
+		if pattern != "#" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	if !requireKeysInScope(c, id, patterns...) {
+		return
+	}
+
+	sort := &redis.Sort{
+		By:    data.By,
+		Get:   data.Get,
+		Order: order,
+		Alpha: data.Alpha,
+	}
+	if len(data.Limit) == 2 {
+		sort.Offset = data.Limit[0]
+		sort.Count = data.Limit[1]
+	}
+
+	result, err := client.SortInterfaces(c, key, sort).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "list, set or zset")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to sort key: %v", err))
+		return
+	}
+
+	// Without GET patterns SORT returns the sorted elements themselves
+	if len(data.Get) == 0 {
+		c.JSON(http.StatusOK, gin.H{"result": normalizeReply(result)})
+		return
+	}
+
+	// With GET patterns the reply is flat, one value per pattern per element.
+	// Group it back into one object per element keyed by pattern.
+	rows := make([]map[string]interface{}, 0, len(result)/len(data.Get))
+	for i := 0; i+len(data.Get) <= len(result); i += len(data.Get) {
+		row := make(map[string]interface{}, len(data.Get))
+		for j, pattern := range data.Get {
+			row[pattern] = normalizeReply(result[i+j])
+		}
+		rows = append(rows, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": rows})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSortGroupsGetPatterns(t *testing.T) {
+	server := newFakeRedis(t, func(args []string) string {
+		if args[0] != "sort" {
+			return "+OK\r\n"
+		}
+		// Two elements, each with the values of "#" and "weight_*"
+		return "*4\r\n$1\r\na\r\n$1\r\n3\r\n$1\r\nb\r\n$1\r\n1\r\n"
+	})
+	const id = "test-sort"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/key/:id/:db/:key/sort", sortKey)
+	sort := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("POST", "/key/"+id+"/0/items/sort", strings.NewReader(body)))
+		return w
+	}
+
+	w := sort(`{"get": ["#", "weight_*"]}`)
+	if w.Code != http.StatusOK || w.Body.String() != `{"result":[{"#":"a","weight_*":"3"},{"#":"b","weight_*":"1"}]}` {
+		t.Errorf("got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := sort(`{"get": ["#", "weight_*", "#"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("duplicate get pattern returned %d, want 400", w.Code)
+	}
+	if sorts := server.received("sort"); len(sorts) != 1 {
+		t.Errorf("SORT ran %d times, want once", len(sorts))
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Startup behavior for saved connections whose server does not answer, set
+// with WEBREDIS_STARTUP_DIAL:
+//
+//   - keep: register the client anyway; go-redis redials on first use, so the
+//     connection recovers once the server is back
+//   - skip: leave it unregistered until it is saved again; it is listed with
+//     the status "skipped"
+//   - abort: refuse to start
+const (
+	startupDialKeep  = "keep"
+	startupDialSkip  = "skip"
+	startupDialAbort = "abort"
+)
+
+var startupPingTimeout = envDuration("WEBREDIS_STARTUP_PING_TIMEOUT", statusPingTimeout)
+
+// skippedConnections holds the startup ping failure of the saved connections
+// left out in skip mode, so listConnections can still show them.
+var (
+	skippedConnections   = make(map[string]connectionStatus)
+	skippedConnectionsMu sync.Mutex
+)
+
+func startupDialMode() string {
+	mode := strings.ToLower(os.Getenv("WEBREDIS_STARTUP_DIAL"))
+	switch mode {
+	case "":
+		return startupDialKeep
+	case startupDialKeep, startupDialSkip, startupDialAbort:
+		return mode
+	}
+	log.Printf("Warning: Invalid WEBREDIS_STARTUP_DIAL %q, using %s", mode, startupDialKeep)
+	return startupDialKeep
+}
+
+// connectSavedConnections builds a client for every saved connection and
+// pings them concurrently. The results seed the status cache, so the first
+// connection list already shows which servers are down.
+func connectSavedConnections(saved []Connection) error {
+	mode := startupDialMode()
+
+	clients := make([]*redis.Client, len(saved))
+	statuses := make([]connectionStatus, len(saved))
+	var wg sync.WaitGroup
+	for i, conn := range saved {
+		clients[i] = newRedisClient(conn)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), startupPingTimeout)
+			defer cancel()
+			statuses[i] = pingConnection(ctx, clients[i])
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, conn := range saved {
+		status := statuses[i]
+		if status.Status != "connected" {
+			log.Printf("Warning: Saved connection %q (%s:%s) did not answer: %s", conn.ID, conn.Host, conn.Port, status.Error)
+			failed = append(failed, conn.ID)
+		}
+	}
+	if mode == startupDialAbort && len(failed) > 0 {
+		for _, client := range clients {
+			client.Close()
+		}
+		return fmt.Errorf("saved connections did not answer: %s", strings.Join(failed, ", "))
+	}
+
+	for i, conn := range saved {
+		status := statuses[i]
+		if status.Status != "connected" && mode == startupDialSkip {
+			clients[i].Close()
+			status.Status = "skipped"
+			skippedConnectionsMu.Lock()
+			skippedConnections[conn.ID] = status
+			skippedConnectionsMu.Unlock()
+			continue
+		}
+		setConnection(conn.ID, clients[i])
+		setConcurrencyLimit(conn.ID, conn.MaxConcurrency)
+		setKeyPrefix(conn.ID, conn.KeyPrefix)
+		setDefaultTTL(conn.ID, conn.DefaultTTL)
+		statusCacheMu.Lock()
+		statusCache[conn.ID] = status
+		statusCacheMu.Unlock()
+	}
+	return nil
+}
+
+// skippedConnectionStatuses returns the saved connections left out at startup.
+func skippedConnectionStatuses() map[string]connectionStatus {
+	skippedConnectionsMu.Lock()
+	defer skippedConnectionsMu.Unlock()
+	result := make(map[string]connectionStatus, len(skippedConnections))
+	for id, status := range skippedConnections {
+		result[id] = status
+	}
+	return result
+}
+
+// forgetSkippedConnection drops a skipped connection once it is saved again
+// or deleted, reporting whether it was skipped.
+func forgetSkippedConnection(id string) bool {
+	skippedConnectionsMu.Lock()
+	defer skippedConnectionsMu.Unlock()
+	_, skipped := skippedConnections[id]
+	delete(skippedConnections, id)
+	return skipped
+}
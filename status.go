@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// statusCacheTTL is how long a ping result is reused, so polling the
+	// connection list does not ping every server on every request.
+	statusCacheTTL = 5 * time.Second
+	// statusPingTimeout bounds each health check ping.
+	statusPingTimeout = 2 * time.Second
+	// pingBenchMaxCount caps the PINGs of one latency benchmark.
+	pingBenchMaxCount = 1000
+)
+
+type connectionStatus struct {
+	Status    string // "connected" or "error"
+	LatencyMs float64
+	Error     string
+	checkedAt time.Time
+}
+
+var (
+	statusCache   = make(map[string]connectionStatus)
+	statusCacheMu sync.Mutex
+)
+
+// connectionStatuses pings the given connections concurrently and returns
+// their status by ID. Recent results are served from the cache.
+func connectionStatuses(ctx context.Context, ids []string) map[string]connectionStatus {
+	result := make(map[string]connectionStatus, len(ids))
+	var stale []string
+
+	statusCacheMu.Lock()
+	for _, id := range ids {
+		if status, ok := statusCache[id]; ok && time.Since(status.checkedAt) < statusCacheTTL {
+			result[id] = status
+		} else {
+			stale = append(stale, id)
+		}
+	}
+	statusCacheMu.Unlock()
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for _, id := range stale {
+		client, exists := getConnection(id)
+		if !exists {
+			continue
+		}
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			status := pingConnection(ctx, client)
+
+			mu.Lock()
+			result[id] = status
+			mu.Unlock()
+
+			statusCacheMu.Lock()
+			statusCache[id] = status
+			statusCacheMu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// pingConnection checks whether a Redis server answers and how fast.
+func pingConnection(ctx context.Context, client *redis.Client) connectionStatus {
+	ctx, cancel := context.WithTimeout(ctx, statusPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := client.Ping(ctx).Err()
+	status := connectionStatus{
+		Status:    "connected",
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		checkedAt: time.Now(),
+	}
+	if err != nil {
+		status.Status = "error"
+		status.LatencyMs = 0
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// forgetConnectionStatus drops the cached status of a connection.
+func forgetConnectionStatus(id string) {
+	statusCacheMu.Lock()
+	delete(statusCache, id)
+	statusCacheMu.Unlock()
+}
+
+// benchmarkPing sends ?count PINGs (20 by default) one after the other and
+// reports their round-trip times in milliseconds. A high minimum points at
+// the network, a low minimum with a high p99 at a busy server.
+func benchmarkPing(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "20"))
+	if err != nil || count <= 0 || count > pingBenchMaxCount {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("count must be between 1 and %d", pingBenchMaxCount))
+		return
+	}
+
+	samples := make([]float64, 0, count)
+	var total float64
+	for i := 0; i < count; i++ {
+		start := time.Now()
+		if err := client.Ping(c).Err(); err != nil {
+			respondRedisError(c, err, fmt.Sprintf("PING %d of %d failed: %v", i+1, count, err))
+			return
+		}
+		ms := float64(time.Since(start).Microseconds()) / 1000
+		samples = append(samples, ms)
+		total += ms
+	}
+	sort.Float64s(samples)
+
+	// Nearest-rank percentiles
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(samples)))) - 1
+		return samples[max(0, rank)]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"count": count,
+		"minMs": samples[0],
+		"maxMs": samples[len(samples)-1],
+		"avgMs": total / float64(count),
+		"p50Ms": percentile(0.50),
+		"p99Ms": percentile(0.99),
+	})
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+type streamGroup struct {
+	Name            string `json:"name"`
+	Consumers       int64  `json:"consumers"`
+	Pending         int64  `json:"pending"`
+	LastDeliveredID string `json:"lastDeliveredId"`
+	EntriesRead     int64  `json:"entriesRead"`
+	Lag             int64  `json:"lag"`
+}
+
+type streamConsumer struct {
+	Name       string `json:"name"`
+	Pending    int64  `json:"pending"`
+	IdleMs     int64  `json:"idleMs"`
+	InactiveMs int64  `json:"inactiveMs"`
+}
+
+type pendingSummary struct {
+	Count     int64            `json:"count"`
+	Lowest    string           `json:"lowest"`
+	Highest   string           `json:"highest"`
+	Consumers map[string]int64 `json:"consumers"`
+}
+
+type pendingEntry struct {
+	ID            string `json:"id"`
+	Consumer      string `json:"consumer"`
+	IdleMs        int64  `json:"idleMs"`
+	DeliveryCount int64  `json:"deliveryCount"`
+}
+
+type streamEntry struct {
+	ID     string                 `json:"id"`
+	Values map[string]interface{} `json:"values"`
+}
+
+// respondStreamError reports a failed stream command, turning the common
+// user errors into 4xx responses.
+func respondStreamError(c *gin.Context, client *redis.Client, key string, err error) {
+	switch {
+	case isWrongType(err):
+		respondWrongType(c, client, key, "stream")
+	case strings.HasPrefix(err.Error(), "NOGROUP"):
+		respondError(c, http.StatusNotFound, errNotFound, err.Error())
+	default:
+		respondRedisError(c, err, err.Error())
+	}
+}
+
+func listStreamGroups(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	groups, err := client.XInfoGroups(c, key).Result()
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	result := make([]streamGroup, len(groups))
+	for i, group := range groups {
+		result[i] = streamGroup{
+			Name:            group.Name,
+			Consumers:       group.Consumers,
+			Pending:         group.Pending,
+			LastDeliveredID: group.LastDeliveredID,
+			EntriesRead:     group.EntriesRead,
+			Lag:             group.Lag,
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func listStreamConsumers(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	group := c.Param("group")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	consumers, err := client.XInfoConsumers(c, key, group).Result()
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	result := make([]streamConsumer, len(consumers))
+	for i, consumer := range consumers {
+		result[i] = streamConsumer{
+			Name:       consumer.Name,
+			Pending:    consumer.Pending,
+			IdleMs:     consumer.Idle.Milliseconds(),
+			InactiveMs: consumer.Inactive.Milliseconds(),
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// getStreamPending returns the XPENDING summary of a group. Passing any of
+// start, end, count, consumer or minIdleMs switches to the detailed form,
+// listing individual pending entries.
+func getStreamPending(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	group := c.Param("group")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	start, hasStart := c.GetQuery("start")
+	end, hasEnd := c.GetQuery("end")
+	countStr, hasCount := c.GetQuery("count")
+	consumer, hasConsumer := c.GetQuery("consumer")
+	minIdleStr, hasMinIdle := c.GetQuery("minIdleMs")
+	detailed := hasStart || hasEnd || hasCount || hasConsumer || hasMinIdle
+
+	if !detailed {
+		summary, err := client.XPending(c, key, group).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			respondStreamError(c, client, key, err)
+			return
+		}
+		result := pendingSummary{Consumers: map[string]int64{}}
+		if summary != nil {
+			result.Count = summary.Count
+			result.Lowest = summary.Lower
+			result.Highest = summary.Higher
+			if summary.Consumers != nil {
+				result.Consumers = summary.Consumers
+			}
+		}
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	if start == "" {
+		start = "-"
+	}
+	if end == "" {
+		end = "+"
+	}
+	count := int64(100)
+	if countStr != "" {
+		var err error
+		count, err = strconv.ParseInt(countStr, 10, 64)
+		if err != nil || count <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid count")
+			return
+		}
+	}
+	var minIdle time.Duration
+	if minIdleStr != "" {
+		ms, err := strconv.ParseInt(minIdleStr, 10, 64)
+		if err != nil || ms < 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid minIdleMs")
+			return
+		}
+		minIdle = time.Duration(ms) * time.Millisecond
+	}
+
+	entries, err := client.XPendingExt(c, &redis.XPendingExtArgs{
+		Stream:   key,
+		Group:    group,
+		Idle:     minIdle,
+		Start:    start,
+		End:      end,
+		Count:    count,
+		Consumer: consumer,
+	}).Result()
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	result := make([]pendingEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = pendingEntry{
+			ID:            entry.ID,
+			Consumer:      entry.Consumer,
+			IdleMs:        entry.Idle.Milliseconds(),
+			DeliveryCount: entry.RetryCount,
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func ackStreamEntries(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	group := c.Param("group")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		IDs []string `json:"ids"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.IDs) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ids is required")
+		return
+	}
+
+	acked, err := client.XAck(c, key, group, data.IDs...).Result()
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"acknowledged": acked})
+}
+
+func claimStreamEntries(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	group := c.Param("group")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Consumer  string   `json:"consumer"`
+		MinIdleMs int64    `json:"minIdleMs"`
+		IDs       []string `json:"ids"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Consumer == "" || len(data.IDs) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "consumer and ids are required")
+		return
+	}
+	if data.MinIdleMs < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "minIdleMs must not be negative")
+		return
+	}
+
+	messages, err := client.XClaim(c, &redis.XClaimArgs{
+		Stream:   key,
+		Group:    group,
+		Consumer: data.Consumer,
+		MinIdle:  time.Duration(data.MinIdleMs) * time.Millisecond,
+		Messages: data.IDs,
+	}).Result()
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"claimed": streamEntries(messages)})
+}
+
+// streamEntries converts go-redis stream messages into their JSON form.
+func streamEntries(messages []redis.XMessage) []streamEntry {
+	entries := make([]streamEntry, len(messages))
+	for i, message := range messages {
+		entries[i] = streamEntry{
+			ID:     message.ID,
+			Values: message.Values,
+		}
+	}
+	return entries
+}
+
+// trimStream trims a stream with XTRIM, either to { "maxLen": n } entries or
+// to entries from { "minId": "..." } on (Redis 6.2+). "approximate": true
+// adds ~, which lets Redis trim whole macro nodes only and is much cheaper,
+// but may leave more entries than asked. XLEN before and after run in the
+// same transaction as the trim. Requires admin mode.
+func trimStream(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		MaxLen      *int64 `json:"maxLen"`
+		MinID       string `json:"minId"`
+		Approximate bool   `json:"approximate"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if (data.MaxLen == nil) == (data.MinID == "") {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Exactly one of maxLen and minId is required")
+		return
+	}
+	if data.MaxLen != nil && *data.MaxLen < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "maxLen must not be negative")
+		return
+	}
+
+	args := []interface{}{"XTRIM", key}
+	if data.MaxLen != nil {
+		args = append(args, "MAXLEN")
+	} else {
+		args = append(args, "MINID")
+	}
+	if data.Approximate {
+		args = append(args, "~")
+	}
+	if data.MaxLen != nil {
+		args = append(args, *data.MaxLen)
+	} else {
+		args = append(args, data.MinID)
+	}
+
+	var before, trimmed, after *redis.IntCmd
+	_, err := client.TxPipelined(c, func(pipe redis.Pipeliner) error {
+		before = pipe.XLen(c, key)
+		trimmed = redis.NewIntCmd(c, args...)
+		pipe.Process(c, trimmed)
+		after = pipe.XLen(c, key)
+		return nil
+	})
+	if err != nil {
+		if data.MinID != "" && strings.Contains(strings.ToLower(err.Error()), "syntax error") {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "XTRIM MINID requires Redis 6.2 or newer")
+			return
+		}
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lengthBefore": before.Val(),
+		"removed":      trimmed.Val(),
+		"lengthAfter":  after.Val(),
+	})
+}
+
+// streamRangeMaxCount caps the entries returned by a time range read.
+const streamRangeMaxCount = 10000
+
+// getStreamRangeByTime reads the entries added between two Unix timestamps
+// in milliseconds, ?from and ?to, both inclusive. Entry IDs start with their
+// creation time, so the bounds become the IDs <from>-0 and <to>-<max>; a
+// missing bound leaves that end open. ?count (default 100) limits the entries
+// and ?reverse=true reads newest first with XREVRANGE. Streams written with
+// explicit IDs that are not timestamps give meaningless results.
+func getStreamRangeByTime(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	start, end := "-", "+"
+	var from, to int64 = 0, -1
+	if s := c.Query("from"); s != "" {
+		var err error
+		if from, err = strconv.ParseInt(s, 10, 64); err != nil || from < 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "from must be a timestamp in milliseconds")
+			return
+		}
+		start = fmt.Sprintf("%d-0", from)
+	}
+	if s := c.Query("to"); s != "" {
+		var err error
+		if to, err = strconv.ParseInt(s, 10, 64); err != nil || to < 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "to must be a timestamp in milliseconds")
+			return
+		}
+		end = fmt.Sprintf("%d-%d", to, ^uint64(0))
+	}
+	if to >= 0 && from > to {
+		respondError(c, http.StatusBadRequest, errBadRequest, "from must not be after to")
+		return
+	}
+
+	count, err := strconv.ParseInt(c.DefaultQuery("count", "100"), 10, 64)
+	if err != nil || count <= 0 || count > streamRangeMaxCount {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("count must be between 1 and %d", streamRangeMaxCount))
+		return
+	}
+
+	var messages []redis.XMessage
+	if c.Query("reverse") == "true" {
+		messages, err = client.XRevRangeN(c, key, end, start, count).Result()
+	} else {
+		messages, err = client.XRangeN(c, key, start, end, count).Result()
+	}
+	if err != nil {
+		respondStreamError(c, client, key, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": streamEntries(messages),
+		"hasMore": int64(len(messages)) == count,
+	})
+}
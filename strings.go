@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// getKeyEx reads a string value and updates its expiry in the same command
+// (GETEX). Pass ttl=<seconds> to set a new expiry or persist=true to remove
+// it; without either it behaves like GET.
+func getKeyEx(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var expiration time.Duration
+	if ttlStr := c.Query("ttl"); ttlStr != "" {
+		ttl, err := strconv.ParseInt(ttlStr, 10, 64)
+		if err != nil || ttl <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "ttl must be a positive number of seconds")
+			return
+		}
+		expiration = time.Duration(ttl) * time.Second
+	}
+	persist := c.Query("persist") == "true"
+	if persist && expiration > 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ttl and persist are mutually exclusive")
+		return
+	}
+
+	var (
+		val string
+		err error
+	)
+	if persist {
+		val, err = client.Do(c, "GETEX", key, "PERSIST").Text()
+	} else {
+		val, err = client.GetEx(c, key, expiration).Result()
+	}
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+			return
+		}
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "string")
+			return
+		}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"type":  "string",
+		"value": decodeValue(val),
+	})
+}
+
+// getKeyRaw writes the bytes of a string value as a file download, without
+// the base64 wrapping getKey uses for binary data. The Content-Type is
+// sniffed from the value unless ?contentType is given; ?filename overrides
+// the download name, which defaults to the key.
+func getKeyRaw(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	val, err := client.Get(c, key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			respondError(c, http.StatusNotFound, errKeyNotFound, fmt.Sprintf("Key '%s' does not exist", key))
+			return
+		}
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "string")
+			return
+		}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	contentType := c.Query("contentType")
+	if contentType == "" {
+		contentType = http.DetectContentType(val)
+	}
+	filename := c.Query("filename")
+	if filename == "" {
+		filename = key
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	c.Data(http.StatusOK, contentType, val)
+}
+
+// uploadKey stores an uploaded file as a string value, byte for byte. The
+// optional ttl form field sets an expiry in seconds.
+func uploadKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxValueBytes)+1<<20)
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Value exceeds %d bytes", maxValueBytes))
+			return
+		}
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid upload: %v", err))
+		return
+	}
+	defer file.Close()
+	if header.Size > int64(maxValueBytes) {
+		respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("Value exceeds %d bytes", maxValueBytes))
+		return
+	}
+
+	var expiration time.Duration
+	if ttlStr := c.Request.FormValue("ttl"); ttlStr != "" {
+		ttl, err := strconv.ParseInt(ttlStr, 10, 64)
+		if err != nil || ttl <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "ttl must be a positive number of seconds")
+			return
+		}
+		expiration = time.Duration(ttl) * time.Second
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Failed to read upload: %v", err))
+		return
+	}
+
+	if err := client.Set(c, key, data, expiration).Err(); err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to set key: %v", err))
+		return
+	}
+	invalidateKeyCount(id, db)
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":  key,
+		"size": len(data),
+	})
+}
+
+// compareAndSetScript sets KEYS[1] to ARGV[3] only if it currently holds
+// ARGV[2], or only if it does not exist when ARGV[1] is "1". ARGV[4] is the
+// new TTL in ms: positive sets it, 0 keeps the current one and -1 removes
+// it. It replies {1} after a swap and {0, current} otherwise.
+var compareAndSetScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if ARGV[1] == '1' then
+	if current then
+		return {0, current}
+	end
+elseif current ~= ARGV[2] then
+	return {0, current}
+end
+local ttl = tonumber(ARGV[4])
+if ttl == 0 and current then
+	ttl = redis.call('PTTL', KEYS[1])
+end
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[3], 'PX', ttl)
+else
+	redis.call('SET', KEYS[1], ARGV[3])
+end
+return {1}
+`)
+
+// compareAndSetKey sets a string key to { "new": ... } only if its value
+// still equals { "expected": ... }, checked and written atomically by a
+// script. "expected": null only sets a key that does not exist yet, for
+// locks. "ttl" is in seconds: omitted or 0 keeps the current TTL, -1
+// removes it. A failed comparison is not an error: the response says
+// swapped=false and carries the current value.
+func compareAndSetKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Expected json.RawMessage `json:"expected"`
+		New      interface{}     `json:"new"`
+		TTL      float64         `json:"ttl"`
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxValueBytes))
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Expected) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "expected is required, null to only set a missing key")
+		return
+	}
+	if data.New == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "new is required")
+		return
+	}
+
+	absent, expected := "0", ""
+	if string(data.Expected) == "null" {
+		absent = "1"
+	} else {
+		var value interface{}
+		if err := json.Unmarshal(data.Expected, &value); err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid expected value: %v", err))
+			return
+		}
+		var err error
+		if expected, err = encodeValue(value); err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("expected: %v", err))
+			return
+		}
+	}
+	newValue, err := encodeValue(data.New)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("new: %v", err))
+		return
+	}
+
+	ttl := int64(-1)
+	switch {
+	case data.TTL > 0:
+		ttl = max(int64(data.TTL*1000), 1)
+	case data.TTL == 0:
+		ttl = 0
+	}
+
+	reply, err := compareAndSetScript.Run(c, client, []string{key}, absent, expected, newValue, ttl).Slice()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "string")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to swap value: %v", err))
+		return
+	}
+
+	if swapped, _ := reply[0].(int64); swapped == 1 {
+		if absent == "1" {
+			adjustKeyCount(id, db, 1)
+		}
+		c.JSON(http.StatusOK, gin.H{"swapped": true})
+		return
+	}
+	var current interface{}
+	if len(reply) > 1 {
+		if raw, ok := reply[1].(string); ok {
+			current = decodeValue(raw)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"swapped": false, "current": current})
+}
+
+// appendKey appends { "value": ... } to a string with APPEND, creating the
+// key if needed, and returns the new length in bytes. Binary data is sent
+// as {"type": "binary", "data": "<base64>"}, as getKey returns it.
+func appendKey(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Value interface{} `json:"value"`
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxValueBytes))
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Value == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "value is required")
+		return
+	}
+	value, err := encodeValue(data.Value)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	length, err := client.Append(c, key, value).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "string")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to append: %v", err))
+		return
+	}
+	// The key did not exist before
+	if length == int64(len(value)) {
+		invalidateKeyCount(id, db)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"length": length})
+}
+
+// setKeyRange overwrites part of a string with SETRANGE, writing { "value":
+// ... } at byte { "offset": n }. A string shorter than the offset is padded
+// with zero bytes first, and a missing key is created. Returns the new
+// length.
+func setKeyRange(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Offset *int64      `json:"offset"`
+		Value  interface{} `json:"value"`
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, int64(maxValueBytes))
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Offset == nil || data.Value == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "offset and value are required")
+		return
+	}
+	if *data.Offset < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "offset must not be negative")
+		return
+	}
+	// Padding counts toward the value size limit too
+	if *data.Offset > int64(maxValueBytes) {
+		respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("offset exceeds %d bytes", maxValueBytes))
+		return
+	}
+	value, err := encodeValue(data.Value)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+		return
+	}
+
+	existed, err := client.Exists(c, key).Result()
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+	length, err := client.SetRange(c, key, *data.Offset, value).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "string")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to set range: %v", err))
+		return
+	}
+	if existed == 0 && length > 0 {
+		adjustKeyCount(id, db, 1)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"length": length})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swapDatabases atomically exchanges the contents of two databases with
+// SWAPDB, e.g. to switch a blue/green dataset. Clients connected to either
+// database see the other one's data immediately.
+func swapDatabases(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data struct {
+		DB1     *int `json:"db1"`
+		DB2     *int `json:"db2"`
+		Confirm bool `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.DB1 == nil || data.DB2 == nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "db1 and db2 are required")
+		return
+	}
+	db1, db2 := *data.DB1, *data.DB2
+	if db1 < 0 || db2 < 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Database numbers must not be negative")
+		return
+	}
+	if db1 == db2 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "db1 and db2 must be different")
+		return
+	}
+	// CONFIG may be disabled on managed servers; SWAPDB then does the range
+	// check itself
+	if config, err := client.ConfigGet(c, "databases").Result(); err == nil {
+		if databases, err := strconv.Atoi(config["databases"]); err == nil && (db1 >= databases || db2 >= databases) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("The server has %d databases (0-%d)", databases, databases-1))
+			return
+		}
+	}
+	if !data.Confirm {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Swapping databases %d and %d affects every client using them, resend with confirm=true", db1, db2))
+		return
+	}
+
+	if err := client.Do(c, "SWAPDB", db1, db2).Err(); err != nil {
+		if strings.Contains(err.Error(), "out of range") {
+			respondError(c, http.StatusBadRequest, errBadRequest, err.Error())
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to swap databases: %v", err))
+		return
+	}
+	invalidateKeyCount(id, strconv.Itoa(db1))
+	invalidateKeyCount(id, strconv.Itoa(db2))
+
+	c.JSON(http.StatusOK, gin.H{"db1": db1, "db2": db2})
+}
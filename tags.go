@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTagLength caps a single connection tag.
+const maxTagLength = 64
+
+// validTag reports whether tag can be stored: not empty, at most
+// maxTagLength bytes and without whitespace, commas or slashes.
+func validTag(tag string) bool {
+	return tag != "" && len(tag) <= maxTagLength && !strings.ContainsAny(tag, " \t\r\n,/")
+}
+
+// addTags adds { "tags": [...] } to a saved connection and returns all of its
+// tags.
+func addTags(c *gin.Context) {
+	id := c.Param("id")
+	if _, err := getConnectionFromDB(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+			return
+		}
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to read connection: %v", err))
+		return
+	}
+
+	var data struct {
+		Tags []string `json:"tags"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Tags) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "tags is required")
+		return
+	}
+	for _, tag := range data.Tags {
+		if !validTag(tag) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid tag '%s': tags are 1 to %d characters without spaces, commas or slashes", tag, maxTagLength))
+			return
+		}
+	}
+
+	if err := addConnectionTags(id, data.Tags); err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to save tags: %v", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tagsOf(id)})
+}
+
+// removeTag removes one tag from a connection.
+func removeTag(c *gin.Context) {
+	id := c.Param("id")
+	tag := c.Param("tag")
+
+	removed, err := removeConnectionTag(id, tag)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, errInternal, fmt.Sprintf("Failed to remove tag: %v", err))
+		return
+	}
+	if !removed {
+		respondError(c, http.StatusNotFound, errNotFound, fmt.Sprintf("Connection '%s' has no tag '%s'", id, tag))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tagsOf(id)})
+}
+
+// tagsOf returns the tags of one connection, or none if they cannot be read.
+func tagsOf(id string) []string {
+	tags, err := loadConnectionTags()
+	if err != nil {
+		log.Printf("Warning: Failed to load connection tags: %v", err)
+		return []string{}
+	}
+	if tags[id] == nil {
+		return []string{}
+	}
+	return tags[id]
+}
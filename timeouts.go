@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxRequestTimeout is the longest timeout a request may ask for with the
+// X-Redis-Timeout header. Larger values are clamped to it.
+var maxRequestTimeout = envDuration("WEBREDIS_MAX_REQUEST_TIMEOUT", 5*time.Minute)
+
+// Gin context keys used by requestTimeout and clientForDB.
+const (
+	requestTimeoutKey = "redisTimeout"
+	requestClientsKey = "redisRequestClients"
+)
+
+// requestTimeout applies the X-Redis-Timeout header (milliseconds) to a
+// single request. The request context gets that deadline, and clientForDB
+// hands out a client whose per-command timeout is raised to match when it is
+// longer than the connection's own, so one heavy operation can run longer
+// without changing the saved settings. Clients created for the request are
+// closed when it ends.
+func requestTimeout(c *gin.Context) {
+	// Handlers may also scope a client themselves, see withCommandTimeout
+	defer closeRequestClients(c)
+
+	header := c.GetHeader("X-Redis-Timeout")
+	if header == "" {
+		c.Next()
+		return
+	}
+
+	ms, err := strconv.ParseInt(header, 10, 64)
+	if err != nil || ms <= 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "X-Redis-Timeout must be a positive number of milliseconds")
+		c.Abort()
+		return
+	}
+	timeout := time.Duration(ms) * time.Millisecond
+	if timeout > maxRequestTimeout {
+		timeout = maxRequestTimeout
+	}
+	c.Header("X-Redis-Timeout", strconv.FormatInt(timeout.Milliseconds(), 10))
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(requestTimeoutKey, timeout)
+
+	c.Next()
+}
+
+// closeRequestClients closes the clients created for a single request.
+func closeRequestClients(c *gin.Context) {
+	if clients, ok := c.Get(requestClientsKey); ok {
+		for _, client := range clients.([]*redis.Client) {
+			client.Close()
+		}
+	}
+}
+
+// withRequestTimeout returns client unchanged, or a request-scoped copy with
+// longer read and write timeouts when X-Redis-Timeout asks for more than the
+// connection allows.
+func withRequestTimeout(c *gin.Context, client *redis.Client) *redis.Client {
+	value, ok := c.Get(requestTimeoutKey)
+	if !ok {
+		return client
+	}
+	return withCommandTimeout(c, client, value.(time.Duration))
+}
+
+// withCommandTimeout returns client unchanged, or a copy scoped to the
+// request with read and write timeouts raised to timeout when the
+// connection's own are shorter.
+func withCommandTimeout(c *gin.Context, client *redis.Client, timeout time.Duration) *redis.Client {
+	options := *client.Options()
+	if timeout <= options.ReadTimeout && timeout <= options.WriteTimeout {
+		return client
+	}
+
+	options.ReadTimeout = timeout
+	options.WriteTimeout = timeout
+	options.MinIdleConns = 0
+	scoped := redis.NewClient(&options)
+	scopeToRequest(c, scoped)
+	return scoped
+}
+
+// scopeToRequest registers a client to be closed when the request ends.
+func scopeToRequest(c *gin.Context, client *redis.Client) {
+	clients, _ := c.Get(requestClientsKey)
+	list, _ := clients.([]*redis.Client)
+	c.Set(requestClientsKey, append(list, client))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// CLIENT TRACKING is a property of a single server connection, so toggling it
+// on a pooled client would only affect whichever connection ran the command.
+// Tracking is therefore enabled on a dedicated connection per webredis
+// connection, kept open until tracking is turned off or the connection is
+// deleted.
+var (
+	trackingConns   = make(map[string]*trackingConn)
+	trackingConnsMu sync.Mutex
+)
+
+// trackingConn is a connection of its own single-connection client, so it
+// never returns to the shared pool with tracking still on. It speaks RESP2
+// and tracks without REDIRECT, so the server has nowhere to deliver
+// invalidation messages and drops them: with RESP3 it would push one for
+// every write matching a BCAST prefix to a socket nobody reads, buffering
+// them without limit and mixing them into the next reply.
+type trackingConn struct {
+	client *redis.Client
+	conn   *redis.Conn
+}
+
+// newTrackingConn opens a RESP2 connection with the options of client.
+func newTrackingConn(client *redis.Client) *trackingConn {
+	options := *client.Options()
+	options.Protocol = 2
+	options.PoolSize = 1
+	options.MinIdleConns = 0
+	dedicated := redis.NewClient(&options)
+	return &trackingConn{client: dedicated, conn: dedicated.Conn()}
+}
+
+func (t *trackingConn) Close() {
+	t.conn.Close()
+	t.client.Close()
+}
+
+// isUnsupportedCommand reports whether the server rejected a command or
+// subcommand it does not know, typically because it is too old.
+func isUnsupportedCommand(err error) bool {
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "unknown subcommand") || strings.Contains(message, "unknown command")
+}
+
+// runOnConn executes an arbitrary command on a dedicated connection.
+func runOnConn(ctx context.Context, conn *redis.Conn, args ...interface{}) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	conn.Process(ctx, cmd)
+	return cmd
+}
+
+// closeTrackingConn releases the tracking connection of id, if any.
+func closeTrackingConn(id string) {
+	trackingConnsMu.Lock()
+	defer trackingConnsMu.Unlock()
+
+	if conn, ok := trackingConns[id]; ok {
+		conn.Close()
+		delete(trackingConns, id)
+	}
+}
+
+func getTrackingInfo(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	trackingConnsMu.Lock()
+	conn, dedicated := trackingConns[id]
+	trackingConnsMu.Unlock()
+
+	var cmd *redis.Cmd
+	if dedicated {
+		cmd = runOnConn(c, conn.conn, "CLIENT", "TRACKINGINFO")
+	} else {
+		cmd = client.Do(c, "CLIENT", "TRACKINGINFO")
+	}
+	reply, err := cmd.Result()
+	if err != nil {
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "Client tracking requires Redis 6.2 or later")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read tracking info: %v", err))
+		return
+	}
+
+	info := replyMap(reply)
+	info["dedicated"] = dedicated
+	c.JSON(http.StatusOK, info)
+}
+
+func setTracking(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	var data struct {
+		Enabled  bool     `json:"enabled"`
+		BCast    bool     `json:"bcast"`
+		Prefixes []string `json:"prefixes"`
+		OptIn    bool     `json:"optin"`
+		OptOut   bool     `json:"optout"`
+		NoLoop   bool     `json:"noloop"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+
+	if !data.Enabled {
+		closeTrackingConn(id)
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	args := []interface{}{"CLIENT", "TRACKING", "ON"}
+	if data.BCast {
+		args = append(args, "BCAST")
+	}
+	for _, prefix := range data.Prefixes {
+		args = append(args, "PREFIX", prefix)
+	}
+	if data.OptIn {
+		args = append(args, "OPTIN")
+	}
+	if data.OptOut {
+		args = append(args, "OPTOUT")
+	}
+	if data.NoLoop {
+		args = append(args, "NOLOOP")
+	}
+
+	// Replace any previous tracking connection so the new options apply
+	closeTrackingConn(id)
+	conn := newTrackingConn(client)
+	if err := runOnConn(c, conn.conn, args...).Err(); err != nil {
+		conn.Close()
+		if isUnsupportedCommand(err) {
+			respondError(c, http.StatusNotImplemented, errNotSupported, "Client tracking requires Redis 6 or later")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to enable tracking: %v", err))
+		return
+	}
+
+	trackingConnsMu.Lock()
+	trackingConns[id] = conn
+	trackingConnsMu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"enabled": true})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// transformMaxReported caps the keys and errors listed in a transform result.
+const transformMaxReported = 1000
+
+// transformMaxKeys caps the keys one transform handles, since each is
+// remembered to never be transformed twice.
+var transformMaxKeys = envInt("WEBREDIS_TRANSFORM_MAX_KEYS", 1000000)
+
+// transformWrapper runs the user's Lua body as a function of the key and its
+// value. It returns 0 when the value is left alone, 1 when it is (or in a dry
+// run would be) replaced, and -1 for keys that are gone or not strings. The
+// TTL is carried over with PTTL/PEXPIRE rather than KEEPTTL, which needs 6.0.
+const transformWrapper = `
+local function transform(key, value)
+%s
+end
+if redis.call('TYPE', KEYS[1]).ok ~= 'string' then
+  return -1
+end
+local value = redis.call('GET', KEYS[1])
+local result = transform(KEYS[1], value)
+if result == nil or result == false then
+  return 0
+end
+result = tostring(result)
+if result == value then
+  return 0
+end
+if ARGV[1] == '1' then
+  return 1
+end
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('SET', KEYS[1], result)
+if ttl > 0 then
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`
+
+// transformKeys rewrites every string value matching a pattern with a Lua
+// function run server-side, so values never travel to webredis. The script is
+// the body of function(key, value) and returns the new value, or nil to leave
+// the key unchanged. Keys are handled in pipelined batches, one EVALSHA per
+// key, so each key is rewritten atomically but the pattern as a whole is not.
+//
+// With dryRun the script runs with EVALSHA_RO (Redis 7.0+), so nothing can be
+// written, not even by a script calling redis.call itself, and the keys that
+// would change are listed. After transformMaxKeys keys the transform stops
+// and reports truncated.
+func transformKeys(c *gin.Context) {
+	if !requireAdmin(c) {
+		return
+	}
+
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Pattern string `json:"pattern"`
+		Script  string `json:"script"`
+		DryRun  bool   `json:"dryRun"`
+		Confirm bool   `json:"confirm"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.Pattern == "" || data.Script == "" {
+		respondError(c, http.StatusBadRequest, errBadRequest, "pattern and script are required")
+		return
+	}
+	if isBroadPattern(data.Pattern) && !data.Confirm {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Pattern '%s' may match every key, resend with confirm=true", data.Pattern))
+		return
+	}
+
+	// Loading compiles the script, so syntax errors surface before any key
+	// is touched
+	sha, err := client.ScriptLoad(c, fmt.Sprintf(transformWrapper, data.Script)).Result()
+	if err != nil {
+		if isRedisServerError(err) {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid script: %v", err))
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to load script: %v", err))
+		return
+	}
+
+	dryRun := "0"
+	if data.DryRun {
+		dryRun = "1"
+		if info, err := serverInfoFor(c, id, client); err == nil && !info.Capabilities.SupportsReadOnlyEval {
+			respondError(c, http.StatusNotImplemented, errNotSupported, fmt.Sprintf("Dry runs need EVALSHA_RO from Redis 7.0 or newer, the server runs %s", info.Version))
+			return
+		}
+	}
+
+	// SCAN may return a key twice, and a transform such as appending a
+	// suffix must not be applied twice
+	seen := make(map[string]bool)
+	truncated := false
+	matched, changed, skipped, failed := 0, 0, 0, 0
+	changedKeys := []string{}
+	errorsByKey := []gin.H{}
+	err = forEachKeyBatch(c, client, scopePattern(id, data.Pattern), func(batch []string) error {
+		keys := batch[:0]
+		for _, key := range batch {
+			if len(seen) >= transformMaxKeys {
+				truncated = true
+				break
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			if truncated {
+				return errStopScan
+			}
+			return nil
+		}
+		matched += len(keys)
+		cmds, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				if data.DryRun {
+					pipe.EvalShaRO(c, sha, []string{key}, dryRun)
+				} else {
+					pipe.EvalSha(c, sha, []string{key}, dryRun)
+				}
+			}
+			return nil
+		})
+		// Script errors are per key and reported below; only a failure of
+		// the pipeline itself stops the transform
+		if err != nil && !isRedisServerError(err) {
+			return fmt.Errorf("failed to transform keys: %w", err)
+		}
+		for i, cmd := range cmds {
+			outcome, err := cmd.(*redis.Cmd).Int64()
+			switch {
+			case err != nil:
+				failed++
+				if len(errorsByKey) < transformMaxReported {
+					errorsByKey = append(errorsByKey, gin.H{"key": keys[i], "error": err.Error()})
+				}
+			case outcome < 0:
+				skipped++
+			case outcome > 0:
+				changed++
+				if len(changedKeys) < transformMaxReported {
+					changedKeys = append(changedKeys, keys[i])
+				}
+			}
+		}
+		if truncated {
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":      data.DryRun,
+		"truncated":   truncated,
+		"matched":     matched,
+		"changed":     changed,
+		"skipped":     skipped,
+		"failed":      failed,
+		"changedKeys": changedKeys,
+		"errors":      errorsByKey,
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTransformDryRun(t *testing.T) {
+	previous, previousMax := adminMode, transformMaxKeys
+	adminMode, transformMaxKeys = true, 2
+	defer func() { adminMode, transformMaxKeys = previous, previousMax }()
+
+	tests := []struct {
+		name      string
+		version   string
+		dryRun    bool
+		wantCode  int
+		wantEvals string // command each key ran with, empty for none
+	}{
+		{"dry run is read-only", "7.2.0", true, http.StatusOK, "evalsha_ro"},
+		{"dry run needs 7.0", "6.2.14", true, http.StatusNotImplemented, ""},
+		{"transform writes", "6.2.14", false, http.StatusOK, "evalsha"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRedis(t, func(args []string) string {
+				switch args[0] {
+				case "info":
+					info := "redis_version:" + tt.version + "\r\n"
+					return fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)
+				case "script":
+					return "$4\r\nsha1\r\n"
+				case "scan":
+					return "*2\r\n$1\r\n0\r\n*3\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n"
+				case "evalsha", "evalsha_ro":
+					return ":1\r\n"
+				}
+				return "+OK\r\n"
+			})
+			const id = "test-transform"
+			setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+			defer func() {
+				client, _ := removeConnection(id)
+				releaseConnection(id, client)
+			}()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/transform/:id/:db", transformKeys)
+			body, _ := json.Marshal(map[string]interface{}{"pattern": "user:*", "script": "return value .. '!'", "dryRun": tt.dryRun})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("POST", "/transform/"+id+"/0", bytes.NewReader(body)))
+			if w.Code != tt.wantCode {
+				t.Fatalf("transform returned %d: %s", w.Code, w.Body.String())
+			}
+
+			evals := append(server.received("evalsha"), server.received("evalsha_ro")...)
+			if tt.wantEvals == "" {
+				if len(evals) != 0 {
+					t.Errorf("scripts ran: %v", evals)
+				}
+				return
+			}
+			// Only transformMaxKeys of the three keys are handled
+			if len(evals) != 2 || len(server.received(tt.wantEvals)) != 2 {
+				t.Errorf("got %v, want 2 %s", evals, tt.wantEvals)
+			}
+			var result struct {
+				Matched   int  `json:"matched"`
+				Truncated bool `json:"truncated"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &result)
+			if result.Matched != 2 || !result.Truncated {
+				t.Errorf("got %+v, want 2 matched and truncated", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// addTTLFields describes a PTTL reply in info. go-redis reports the special
+// PTTL replies as -1 (no expiry) and -2 (no such key) nanoseconds; these get
+// named flags instead of leaking through as tiny negative numbers.
+//
+//	ttl        seconds, -1 without expiry, -2 when the key is missing
+//	pttl       milliseconds, same conventions as ttl
+//	ttlHuman   readable remaining time, e.g. "2h 13m"
+//	persistent true when the key exists but never expires
+//	missing    true when the key no longer exists
+func addTTLFields(info map[string]interface{}, pttl time.Duration) {
+	switch pttl {
+	case -1:
+		info["ttl"] = -1
+		info["pttl"] = -1
+		info["ttlHuman"] = "no expiry"
+		info["persistent"] = true
+		info["missing"] = false
+	case -2:
+		info["ttl"] = -2
+		info["pttl"] = -2
+		info["ttlHuman"] = "missing"
+		info["persistent"] = false
+		info["missing"] = true
+	default:
+		info["ttl"] = pttl.Seconds()
+		info["pttl"] = pttl.Milliseconds()
+		info["ttlHuman"] = formatTTL(pttl)
+		info["persistent"] = false
+		info["missing"] = false
+	}
+}
+
+// formatTTL renders a remaining time using its two most significant units,
+// e.g. "3d 4h", "2h 13m" or "45s". Sub-second values are shown in milliseconds.
+func formatTTL(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+
+	units := []struct {
+		size   time.Duration
+		suffix string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+	}
+
+	for i, unit := range units {
+		if d < unit.size {
+			continue
+		}
+		text := fmt.Sprintf("%d%s", d/unit.size, unit.suffix)
+		if i+1 < len(units) {
+			next := units[i+1]
+			if rest := (d % unit.size) / next.size; rest > 0 {
+				text += fmt.Sprintf(" %d%s", rest, next.suffix)
+			}
+		}
+		return text
+	}
+	return "0s"
+}
+
+// setKeyTTL updates the expiry of a key. ttl is in seconds and -1 removes the
+// expiry (PERSIST). The optional option (nx, xx, gt or lt) makes EXPIRE
+// conditional, e.g. gt only ever extends an expiry; it needs Redis 7.
+func setKeyTTL(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		TTL    int64  `json:"ttl"`
+		Option string `json:"option"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if data.TTL == 0 || data.TTL < -1 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "ttl must be a positive number of seconds, or -1 to remove the expiry")
+		return
+	}
+
+	var (
+		changed bool
+		err     error
+	)
+	if data.TTL == -1 {
+		if data.Option != "" {
+			respondError(c, http.StatusBadRequest, errBadRequest, "option cannot be used with ttl -1")
+			return
+		}
+		changed, err = client.Persist(c, key).Result()
+	} else {
+		ttl := time.Duration(data.TTL) * time.Second
+		option := strings.ToLower(data.Option)
+		if option != "" {
+			switch option {
+			case "nx", "xx", "gt", "lt":
+			default:
+				respondError(c, http.StatusBadRequest, errBadRequest, "option must be nx, xx, gt or lt")
+				return
+			}
+			server, _ := getConnection(id)
+			info, infoErr := serverInfoFor(c, id, server)
+			if infoErr == nil && !info.Capabilities.SupportsExpireOptions {
+				respondError(c, http.StatusNotImplemented, errNotSupported, fmt.Sprintf("EXPIRE %s requires Redis 7.0 or newer, the server runs %s", strings.ToUpper(option), info.Version))
+				return
+			}
+		}
+
+		switch option {
+		case "nx":
+			changed, err = client.ExpireNX(c, key, ttl).Result()
+		case "xx":
+			changed, err = client.ExpireXX(c, key, ttl).Result()
+		case "gt":
+			changed, err = client.ExpireGT(c, key, ttl).Result()
+		case "lt":
+			changed, err = client.ExpireLT(c, key, ttl).Result()
+		default:
+			changed, err = client.Expire(c, key, ttl).Result()
+		}
+	}
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to set TTL: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changed": changed})
+}
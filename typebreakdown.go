@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// typeBreakdownSampleSize is the default number of keys the type breakdown
+// looks at; ?full=true scans them all instead.
+var typeBreakdownSampleSize = envInt("WEBREDIS_TYPE_BREAKDOWN_SAMPLE_SIZE", 10000)
+
+// getTypeBreakdown counts keys per type, module types such as "ReJSON-RL"
+// included, with pipelined TYPE lookups over a SCAN. By default it stops
+// after ?sample keys; exact is true when the scan covered every key, either
+// with ?full=true or because the database is smaller than the sample. Keys
+// changed while scanning can make even a full count slightly off.
+func getTypeBreakdown(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	full := c.Query("full") == "true"
+	sample := typeBreakdownSampleSize
+	if s := c.Query("sample"); s != "" {
+		var err error
+		sample, err = strconv.Atoi(s)
+		if err != nil || sample <= 0 {
+			respondError(c, http.StatusBadRequest, errBadRequest, "Invalid sample")
+			return
+		}
+	}
+
+	counted := 0
+	stopped := false
+	types := make(map[string]int)
+	err := forEachKeyBatch(c, client, scopePattern(id, c.DefaultQuery("pattern", "*")), func(keys []string) error {
+		if remaining := sample - counted; !full && len(keys) > remaining {
+			keys = keys[:remaining]
+		}
+		cmds := make([]*redis.StatusCmd, len(keys))
+		if _, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+			for i, key := range keys {
+				cmds[i] = pipe.Type(c, key)
+			}
+			return nil
+		}); err != nil {
+			return fmt.Errorf("failed to read key types: %w", err)
+		}
+		for _, cmd := range cmds {
+			// Deleted since the scan
+			if cmd.Val() == "none" {
+				continue
+			}
+			types[cmd.Val()]++
+			counted++
+		}
+		if !full && counted >= sample {
+			stopped = true
+			return errStopScan
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sampled": counted,
+		"exact":   !stopped,
+		"types":   types,
+	})
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// valueReader decodes the reply of a command queued by queueValueRead. It
+// must only be called after the pipeline has been executed.
+type valueReader func() (interface{}, error)
+
+// queueValueRead queues on pipe the command that reads a whole key of the
+// given type and returns the function decoding its reply. It returns nil for
+// types that webredis cannot display.
+func queueValueRead(ctx context.Context, pipe redis.Pipeliner, key, keyType string) valueReader {
+	return queueValueReadAs(ctx, pipe, key, keyType, decodeValue)
+}
+
+// queueValueReadAs is queueValueRead with decode applied to every stored
+// string, e.g. rawValue to keep them byte for byte.
+func queueValueReadAs(ctx context.Context, pipe redis.Pipeliner, key, keyType string, decode func(string) interface{}) valueReader {
+	switch keyType {
+	case "string":
+		cmd := pipe.Get(ctx, key)
+		return func() (interface{}, error) {
+			val, err := cmd.Result()
+			if err != nil {
+				return nil, err
+			}
+			return decode(val), nil
+		}
+	case "list":
+		cmd := pipe.LRange(ctx, key, 0, -1)
+		return func() (interface{}, error) {
+			val, err := cmd.Result()
+			if err != nil {
+				return nil, err
+			}
+			return decodeValuesAs(val, decode), nil
+		}
+	case "set":
+		cmd := pipe.SMembers(ctx, key)
+		return func() (interface{}, error) {
+			val, err := cmd.Result()
+			if err != nil {
+				return nil, err
+			}
+			return decodeValuesAs(val, decode), nil
+		}
+	case "hash":
+		cmd := pipe.HGetAll(ctx, key)
+		return func() (interface{}, error) {
+			val, err := cmd.Result()
+			if err != nil {
+				return nil, err
+			}
+			// Try to parse each value as JSON or handle binary data
+			parsedHash := make(map[string]interface{}, len(val))
+			for k, v := range val {
+				parsedHash[k] = decode(v)
+			}
+			return parsedHash, nil
+		}
+	case "zset":
+		cmd := pipe.ZRangeWithScores(ctx, key, 0, -1)
+		return func() (interface{}, error) {
+			val, err := cmd.Result()
+			if err != nil {
+				return nil, err
+			}
+			return decodeZSliceAs(val, decode), nil
+		}
+	}
+	return nil
+}
+
+// decodeValues decodes every item of a list or set reply.
+func decodeValues(items []string) []interface{} {
+	return decodeValuesAs(items, decodeValue)
+}
+
+func decodeValuesAs(items []string, decode func(string) interface{}) []interface{} {
+	parsed := make([]interface{}, len(items))
+	for i, item := range items {
+		parsed[i] = decode(item)
+	}
+	return parsed
+}
+
+// decodeZSlice converts sorted set members into {score, member} objects.
+func decodeZSlice(members []redis.Z) []map[string]interface{} {
+	return decodeZSliceAs(members, decodeValue)
+}
+
+func decodeZSliceAs(members []redis.Z, decode func(string) interface{}) []map[string]interface{} {
+	parsed := make([]map[string]interface{}, len(members))
+	for i, z := range members {
+		parsed[i] = map[string]interface{}{
+			"score":  z.Score,
+			"member": decode(fmt.Sprintf("%v", z.Member)),
+		}
+	}
+	return parsed
+}
+
+// rawValue keeps a stored string exactly as it is: text as a plain string,
+// binary data as a base64 {type: "binary"} object. Unlike decodeValue it
+// never parses JSON, so "1.50" or "\"abc\"" survive an export unchanged.
+func rawValue(raw string) interface{} {
+	if isBinary(raw) {
+		return binaryValue(raw)
+	}
+	return raw
+}
+
+// encodeValue is the inverse of decodeValue: binary objects are turned back
+// into their raw bytes and anything else is stored as by stringValue.
+func encodeValue(v interface{}) (string, error) {
+	if obj, ok := v.(map[string]interface{}); ok && len(obj) == 2 && obj["type"] == "binary" {
+		if data, ok := obj["data"].(string); ok {
+			raw, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				return "", fmt.Errorf("invalid binary data: %w", err)
+			}
+			return string(raw), nil
+		}
+	}
+	return stringValue(v)
+}
+
+// rawString is the inverse of rawValue: strings are stored verbatim and
+// binary objects as their bytes. Anything else is rejected, since rawValue
+// never produces it.
+func rawString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case map[string]interface{}:
+		if v["type"] == "binary" {
+			return encodeValue(v)
+		}
+	}
+	return "", errors.New("values must be strings or {\"type\": \"binary\"} objects")
+}
+
+// writeChunkSize is how many elements go into a single RPUSH, SADD, HSET or
+// ZADD when a collection is written.
+const writeChunkSize = 1000
+
+// queueValueWrite queues on pipe the commands that replace key with value,
+// given in the form returned by queueValueRead. Large collections are split
+// into several commands of writeChunkSize elements.
+func queueValueWrite(ctx context.Context, pipe redis.Pipeliner, key, keyType string, value interface{}) error {
+	return queueValueWriteAs(ctx, pipe, key, keyType, value, encodeValue)
+}
+
+// queueValueWriteAs is queueValueWrite with encode turning every string,
+// element and field value into the bytes stored, e.g. rawString for values
+// read with rawValue.
+func queueValueWriteAs(ctx context.Context, pipe redis.Pipeliner, key, keyType string, value interface{}, encode func(interface{}) (string, error)) error {
+	switch keyType {
+	case "string":
+		str, err := encode(value)
+		if err != nil {
+			return err
+		}
+		pipe.Set(ctx, key, str, 0)
+		return nil
+	case "list", "set":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s value for '%s' must be an array", keyType, key)
+		}
+		members := make([]interface{}, len(items))
+		for i, item := range items {
+			str, err := encode(item)
+			if err != nil {
+				return err
+			}
+			members[i] = str
+		}
+		pipe.Del(ctx, key)
+		for start := 0; start < len(members); start += writeChunkSize {
+			chunk := members[start:min(start+writeChunkSize, len(members))]
+			if keyType == "list" {
+				pipe.RPush(ctx, key, chunk...)
+			} else {
+				pipe.SAdd(ctx, key, chunk...)
+			}
+		}
+		return nil
+	case "hash":
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("hash value for '%s' must be an object", key)
+		}
+		pairs := make([]interface{}, 0, len(fields)*2)
+		for field, v := range fields {
+			str, err := encode(v)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, field, str)
+		}
+		pipe.Del(ctx, key)
+		for start := 0; start < len(pairs); start += 2 * writeChunkSize {
+			pipe.HSet(ctx, key, pairs[start:min(start+2*writeChunkSize, len(pairs))]...)
+		}
+		return nil
+	case "zset":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("zset value for '%s' must be an array", key)
+		}
+		members := make([]redis.Z, len(items))
+		for i, item := range items {
+			obj, ok := item.(map[string]interface{})
+			score, scoreOK := obj["score"].(float64)
+			if !ok || !scoreOK {
+				return fmt.Errorf("zset members of '%s' must be { score, member } objects", key)
+			}
+			member, err := encode(obj["member"])
+			if err != nil {
+				return err
+			}
+			members[i] = redis.Z{Score: score, Member: member}
+		}
+		pipe.Del(ctx, key)
+		for start := 0; start < len(members); start += writeChunkSize {
+			pipe.ZAdd(ctx, key, members[start:min(start+writeChunkSize, len(members))]...)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported type '%s' for '%s'", keyType, key)
+}
+
+// mgetMaxKeys caps how many keys a single multi-get request may read.
+var mgetMaxKeys = envInt("WEBREDIS_MGET_MAX_KEYS", 100)
+
+// multiGetKeys reads several keys of any type in two round trips: one
+// pipeline for their types and one for their values.
+func multiGetKeys(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Keys []string `json:"keys"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Keys) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "keys is required")
+		return
+	}
+	if len(data.Keys) > mgetMaxKeys {
+		respondError(c, http.StatusRequestEntityTooLarge, errPayloadTooLarge, fmt.Sprintf("At most %d keys can be read at once", mgetMaxKeys))
+		return
+	}
+	if !requireKeysInScope(c, id, data.Keys...) {
+		return
+	}
+
+	typeCmds := make([]*redis.StatusCmd, len(data.Keys))
+	_, err := client.Pipelined(c, func(pipe redis.Pipeliner) error {
+		for i, key := range data.Keys {
+			typeCmds[i] = pipe.Type(c, key)
+		}
+		return nil
+	})
+	if err != nil {
+		respondRedisError(c, err, fmt.Sprintf("Failed to read key types: %v", err))
+		return
+	}
+
+	result := make(map[string]gin.H, len(data.Keys))
+	readers := make(map[string]valueReader, len(data.Keys))
+	pipe := client.Pipeline()
+	for i, key := range data.Keys {
+		keyType := typeCmds[i].Val()
+		if keyType == "none" {
+			result[key] = gin.H{"type": keyType, "exists": false}
+			continue
+		}
+		read := queueValueRead(c, pipe, key, keyType)
+		if read == nil {
+			result[key] = gin.H{"type": keyType, "error": "Unsupported key type"}
+			continue
+		}
+		readers[key] = read
+	}
+
+	// Per-key failures (e.g. a key replaced by another type in between) are
+	// reported in that key's entry rather than failing the whole request
+	if len(readers) > 0 {
+		pipe.Exec(c)
+	}
+	for i, key := range data.Keys {
+		read, ok := readers[key]
+		if !ok {
+			continue
+		}
+		value, err := read()
+		if err != nil {
+			if isTimeout(err) || isUnreachable(err) {
+				respondRedisError(c, err, fmt.Sprintf("Failed to read keys: %v", err))
+				return
+			}
+			result[key] = gin.H{"type": typeCmds[i].Val(), "error": err.Error()}
+			continue
+		}
+		result[key] = gin.H{"type": typeCmds[i].Val(), "value": value}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func listValue(n int) []interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("element-%d", i)
+	}
+	return items
+}
+
+func TestQueueValueWriteChunksCollections(t *testing.T) {
+	// Commands are only queued, so the address is never dialed
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer client.Close()
+
+	const n = 100_000
+	pipe := client.Pipeline()
+	if err := queueValueWrite(context.Background(), pipe, "list", "list", listValue(n)); err != nil {
+		t.Fatal(err)
+	}
+	// DEL, then one RPUSH per chunk
+	if want := 1 + (n+writeChunkSize-1)/writeChunkSize; pipe.Len() != want {
+		t.Errorf("queued %d commands, want %d", pipe.Len(), want)
+	}
+}
+
+// BenchmarkListValueWrite measures the write of a 100k-element list as setKey
+// runs it: DEL and the chunked RPUSH commands in one pipeline, sent to a fake
+// server so the round trips are included.
+func BenchmarkListValueWrite(b *testing.B) {
+	server := newFakeRedis(b, func(args []string) string {
+		return ":1\r\n"
+	})
+	client := redis.NewClient(&redis.Options{Addr: server.addr})
+	defer client.Close()
+	items := listValue(100_000)
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pipe := client.Pipeline()
+		if err := queueValueWrite(ctx, pipe, "list", "list", items); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
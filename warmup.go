@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// warmupPings is the number of PINGs in the warmup latency sample.
+	warmupPings = 5
+	// warmupDefaultDatabases is assumed when CONFIG GET databases is not
+	// allowed, as in listDatabases.
+	warmupDefaultDatabases = 16
+)
+
+type warmupCheck struct {
+	Name  string  `json:"name"`
+	OK    bool    `json:"ok"`
+	Ms    float64 `json:"ms"`
+	Error string  `json:"error,omitempty"`
+}
+
+// warmupConnection exercises a connection beyond a single PING, so missing
+// ACL permissions or renamed commands show up right after it is added
+// instead of on first use. Every step is reported in checks; a failing step
+// does not stop the others. The PING sample refreshes the cached status and
+// the per-database counts of INFO keyspace seed the key count cache.
+func warmupConnection(c *gin.Context) {
+	id := c.Param("id")
+	client, exists := getConnection(id)
+	if !exists {
+		respondError(c, http.StatusNotFound, errConnectionNotFound, "Connection not found")
+		return
+	}
+
+	checks := []warmupCheck{}
+	check := func(name string, fn func() error) bool {
+		start := time.Now()
+		err := fn()
+		result := warmupCheck{Name: name, OK: err == nil, Ms: float64(time.Since(start).Microseconds()) / 1000}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		checks = append(checks, result)
+		return err == nil
+	}
+
+	report := gin.H{}
+
+	var (
+		latencies []float64
+		status    connectionStatus
+	)
+	reachable := check("PING", func() error {
+		for i := 0; i < warmupPings; i++ {
+			status = pingConnection(c, client)
+			if status.Error != "" {
+				return errors.New(status.Error)
+			}
+			latencies = append(latencies, status.LatencyMs)
+		}
+		return nil
+	})
+	statusCacheMu.Lock()
+	statusCache[id] = status
+	statusCacheMu.Unlock()
+	report["reachable"] = reachable
+	if !reachable {
+		// Nothing else can work
+		report["checks"] = checks
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	report["latency"] = latencySummary(latencies)
+
+	info, infoErr := serverInfoFor(c, id, client)
+	if infoErr == nil {
+		report["version"] = info.Version
+		report["mode"] = info.Mode
+	}
+
+	databases := warmupDefaultDatabases
+	check("CONFIG GET databases", func() error {
+		config, err := client.ConfigGet(c, "databases").Result()
+		if err != nil {
+			return err
+		}
+		if n, err := strconv.Atoi(config["databases"]); err == nil && n > 0 {
+			databases = n
+		}
+		return nil
+	})
+	// A cluster node only has database 0
+	if infoErr == nil && info.Mode == "cluster" {
+		databases = 1
+	}
+
+	var totalKeys int64
+	keysPerDB := make(map[string]int64)
+	check("INFO keyspace", func() error {
+		raw, err := client.Info(c, "keyspace").Result()
+		if err != nil {
+			return err
+		}
+		counts := parseKeyspaceInfo(raw)
+		fetchedAt := time.Now()
+		keyCountsMu.Lock()
+		defer keyCountsMu.Unlock()
+		// Empty databases are not listed, so they are seeded with 0
+		listed := databases
+		for i := range counts {
+			listed = max(listed, i+1)
+		}
+		for i := 0; i < listed; i++ {
+			db := strconv.Itoa(i)
+			count := counts[i]
+			if count > 0 {
+				keysPerDB[db] = count
+			}
+			totalKeys += count
+			keyCounts[keyCountKey(id, db)] = &keyCount{count: count, fetchedAt: fetchedAt}
+		}
+		return nil
+	})
+	report["totalKeys"] = totalKeys
+	report["keysPerDb"] = keysPerDB
+
+	check("SCAN", func() error {
+		return client.Scan(c, 0, scopePattern(id, "*"), 10).Err()
+	})
+
+	check("SLOWLOG LEN", func() error {
+		length, err := client.Do(c, "SLOWLOG", "LEN").Int64()
+		if err == nil {
+			report["slowlogLength"] = length
+		}
+		return err
+	})
+
+	check("INFO memory", func() error {
+		raw, err := client.Info(c, "memory").Result()
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(raw, "\n") {
+			name, value, _ := strings.Cut(strings.TrimSpace(line), ":")
+			switch name {
+			case "used_memory":
+				report["usedMemory"], _ = strconv.ParseInt(value, 10, 64)
+			case "used_memory_human":
+				report["usedMemoryHuman"] = value
+			}
+		}
+		return nil
+	})
+
+	healthy := true
+	for _, result := range checks {
+		healthy = healthy && result.OK
+	}
+	report["healthy"] = healthy
+	report["checks"] = checks
+	c.JSON(http.StatusOK, report)
+}
+
+// parseKeyspaceInfo returns the number of keys per database from INFO
+// keyspace, whose lines look like "db0:keys=12,expires=3,avg_ttl=0".
+func parseKeyspaceInfo(raw string) map[int]int64 {
+	counts := make(map[int]int64)
+	for _, line := range strings.Split(raw, "\n") {
+		name, fields, ok := strings.Cut(strings.TrimSpace(line), ":")
+		index, err := strconv.Atoi(strings.TrimPrefix(name, "db"))
+		if !ok || !strings.HasPrefix(name, "db") || err != nil {
+			continue
+		}
+		for _, field := range strings.Split(fields, ",") {
+			if value, ok := strings.CutPrefix(field, "keys="); ok {
+				counts[index], _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+	}
+	return counts
+}
+
+// latencySummary reports the min, average and max of latency samples in ms.
+func latencySummary(samples []float64) gin.H {
+	low, high, total := samples[0], samples[0], 0.0
+	for _, ms := range samples {
+		low, high = min(low, ms), max(high, ms)
+		total += ms
+	}
+	return gin.H{
+		"minMs": low,
+		"avgMs": total / float64(len(samples)),
+		"maxMs": high,
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyspaceInfo(t *testing.T) {
+	raw := "# Keyspace\r\ndb0:keys=12,expires=3,avg_ttl=0\r\ndb5:keys=1,expires=0,avg_ttl=0,subexpiry=0\r\n\r\n"
+	want := map[int]int64{0: 12, 5: 1}
+	if got := parseKeyspaceInfo(raw); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseKeyspaceInfo() = %v, want %v", got, want)
+	}
+	if got := parseKeyspaceInfo("# Keyspace\r\n"); len(got) != 0 {
+		t.Errorf("empty keyspace parsed as %v", got)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// addZSetMembers adds or updates members without rebuilding the key, with
+// the ZADD condition flags: nx only adds, xx only updates, gt and lt only
+// move a score up or down. ch makes the reply count updated members as well
+// as added ones.
+func addZSetMembers(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	var data struct {
+		Members []struct {
+			Score  *float64    `json:"score"`
+			Member interface{} `json:"member"`
+		} `json:"members"`
+		NX bool `json:"nx"`
+		XX bool `json:"xx"`
+		GT bool `json:"gt"`
+		LT bool `json:"lt"`
+		CH bool `json:"ch"`
+	}
+
+	if err := c.ShouldBindJSON(&data); err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("Invalid request data: %v", err))
+		return
+	}
+	if len(data.Members) == 0 {
+		respondError(c, http.StatusBadRequest, errBadRequest, "members is required")
+		return
+	}
+	// The same combinations ZADD itself rejects
+	switch {
+	case data.NX && data.XX:
+		respondError(c, http.StatusBadRequest, errBadRequest, "nx and xx are mutually exclusive")
+		return
+	case data.GT && data.LT:
+		respondError(c, http.StatusBadRequest, errBadRequest, "gt and lt are mutually exclusive")
+		return
+	case data.NX && (data.GT || data.LT):
+		respondError(c, http.StatusBadRequest, errBadRequest, "nx cannot be combined with gt or lt")
+		return
+	}
+
+	members := make([]redis.Z, len(data.Members))
+	for i, m := range data.Members {
+		if m.Score == nil || m.Member == nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("members[%d] needs a score and a member", i))
+			return
+		}
+		member, err := encodeValue(m.Member)
+		if err != nil {
+			respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("members[%d]: %v", i, err))
+			return
+		}
+		members[i] = redis.Z{Score: *m.Score, Member: member}
+	}
+
+	changed, err := client.ZAddArgs(c, key, redis.ZAddArgs{
+		NX:      data.NX,
+		XX:      data.XX,
+		GT:      data.GT,
+		LT:      data.LT,
+		Ch:      data.CH,
+		Members: members,
+	}).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "zset")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to add members: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"changed": changed})
+}
+
+// zsetRankMaxCount caps how many members one rank range may return.
+const zsetRankMaxCount = 1000
+
+// getZSetRankRange returns the members of a sorted set between two ranks,
+// e.g. the top 10 of a leaderboard with ?start=0&stop=9&rev=true. Negative
+// ranks count from the end like in ZRANGE; ?withscores=false leaves the
+// scores out.
+func getZSetRankRange(c *gin.Context) {
+	id := c.Param("id")
+	db := c.Param("db")
+	key := c.Param("key")
+	client, ok := clientForDB(c, id, db)
+	if !ok {
+		return
+	}
+
+	start, err := strconv.ParseInt(c.DefaultQuery("start", "0"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid start")
+		return
+	}
+	stop, err := strconv.ParseInt(c.DefaultQuery("stop", "9"), 10, 64)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, errBadRequest, "Invalid stop")
+		return
+	}
+	rev := c.Query("rev") == "true"
+	withScores := c.Query("withscores") != "false"
+
+	card, err := client.ZCard(c, key).Result()
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "zset")
+			return
+		}
+		respondRedisError(c, err, err.Error())
+		return
+	}
+
+	if (start >= 0) == (stop >= 0) && start > stop {
+		respondError(c, http.StatusBadRequest, errBadRequest, "start must not be after stop")
+		return
+	}
+	// Resolve negative ranks against the current size and clamp stop to the
+	// last member, so ?stop=1000000 on a small set is not refused. The
+	// resolved ranks are sent, so a set growing meanwhile cannot make the
+	// reply larger than checked here
+	from, to := start, stop
+	if from < 0 {
+		from = max(from+card, 0)
+	}
+	if to < 0 {
+		to += card
+	}
+	to = min(to, card-1)
+	if from > to {
+		c.JSON(http.StatusOK, gin.H{
+			"members": []interface{}{},
+			"total":   card,
+		})
+		return
+	}
+	if to-from+1 > zsetRankMaxCount {
+		respondError(c, http.StatusBadRequest, errBadRequest, fmt.Sprintf("At most %d members can be read at once", zsetRankMaxCount))
+		return
+	}
+	start, stop = from, to
+
+	// ZREVRANGE rather than ZRANGE ... REV, which needs Redis 6.2
+	var members interface{}
+	switch {
+	case withScores && rev:
+		var zs []redis.Z
+		zs, err = client.ZRevRangeWithScores(c, key, start, stop).Result()
+		members = decodeZSlice(zs)
+	case withScores:
+		var zs []redis.Z
+		zs, err = client.ZRangeWithScores(c, key, start, stop).Result()
+		members = decodeZSlice(zs)
+	case rev:
+		var names []string
+		names, err = client.ZRevRange(c, key, start, stop).Result()
+		members = decodeValues(names)
+	default:
+		var names []string
+		names, err = client.ZRange(c, key, start, stop).Result()
+		members = decodeValues(names)
+	}
+	if err != nil {
+		if isWrongType(err) {
+			respondWrongType(c, client, key, "zset")
+			return
+		}
+		respondRedisError(c, err, fmt.Sprintf("Failed to read range: %v", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"members": members,
+		"total":   card,
+	})
+}
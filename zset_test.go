@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestZSetRankRangeClampsStop(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		card      int
+		sentRange string // bounds of the last ZRANGE or ZREVRANGE
+	)
+	server := newFakeRedis(t, func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		switch args[0] {
+		case "zcard":
+			return fmt.Sprintf(":%d\r\n", card)
+		case "zrange", "zrevrange":
+			sentRange = args[2] + " " + args[3]
+			return "*1\r\n$6\r\nplayer\r\n"
+		}
+		return "+OK\r\n"
+	})
+	const id = "test-zrank"
+	setConnection(id, redis.NewClient(&redis.Options{Addr: server.addr}))
+	defer func() {
+		client, _ := removeConnection(id)
+		releaseConnection(id, client)
+	}()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/key/:id/:db/:key/zrank-range", getZSetRankRange)
+
+	tests := []struct {
+		card      int
+		query     string
+		wantCode  int
+		wantRange string // ZRANGE/ZREVRANGE bounds sent, empty for none
+	}{
+		{5, "start=0&stop=1000000&withscores=false", http.StatusOK, "0 4"},
+		{5, "start=-3&stop=-1&withscores=false", http.StatusOK, "2 4"},
+		{5, "start=-100&stop=1&rev=true&withscores=false", http.StatusOK, "0 1"},
+		{5, "start=10&stop=20&withscores=false", http.StatusOK, ""},
+		{0, "start=0&stop=-1&withscores=false", http.StatusOK, ""},
+		{5000, "start=0&stop=-1&withscores=false", http.StatusBadRequest, ""},
+		{5000, "start=0&stop=999&withscores=false", http.StatusOK, "0 999"},
+		{5, "start=3&stop=1", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		mu.Lock()
+		card, sentRange = tt.card, ""
+		mu.Unlock()
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/key/"+id+"/0/board/zrank-range?"+tt.query, nil))
+		if w.Code != tt.wantCode {
+			t.Errorf("card %d, %s: got %d %s, want %d", tt.card, tt.query, w.Code, w.Body.String(), tt.wantCode)
+			continue
+		}
+		mu.Lock()
+		if sentRange != tt.wantRange {
+			t.Errorf("card %d, %s: read range %q, want %q", tt.card, tt.query, sentRange, tt.wantRange)
+		}
+		mu.Unlock()
+	}
+}